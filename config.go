@@ -2,6 +2,8 @@ package logger
 
 import (
 	"context"
+	"log"
+	"time"
 )
 
 const (
@@ -17,8 +19,69 @@ type LoggerConfig struct {
 	Level       int
 	Buffer      int
 	Output      []LogDriver
+	Drivers     []DriverConfig
 	TagsFromCtx map[string]string
 	NeedToLog   NeedToLogDeterminant
+	// DisableStringContextKeys отключает поиск тегов TagsFromCtx и пользователя "userForLog"
+	// по строковым ключам контекста, остаются только ContextWithTag, ContextWithTags и ContextWithUser
+	DisableStringContextKeys bool
+	// ContextExtractors дополнительно достают из контекста теги, экстру и пользователя
+	ContextExtractors []ContextExtractor
+	// Processors обрабатывают каждое сообщение перед отправкой в драйверы, по порядку
+	Processors []Processor
+	// Sampling сэмплирование по уровням, уровни без настройки не сэмплируются
+	Sampling map[int]Sampling
+	// DedupWindow окно подавления одинаковых сообщений (уровень, текст, теги, место вызова), 0 отключает.
+	// Повторы в окне не отправляются, после окна приходит одно сообщение "repeated N times in T"
+	DedupWindow time.Duration
+	// SpanEvents дублирует сообщения событиями в активный спан OpenTelemetry из контекста
+	SpanEvents bool
+	// LevelOverrides уровни для дочерних логгеров Logger.Named по префиксу имени,
+	// например {"billing": DEBUG, "billing.invoices": TRACE}, см. ParseLevelOverrides
+	LevelOverrides map[string]int
+	// TimeFormat формат поля date, см. TimeFormatDefault, TimeFormatRFC3339Nano, TimeFormatUnixMilli
+	// или любой layout для time.Format
+	TimeFormat string
+	// TimeLocation часовой пояс поля date, по умолчанию UTC
+	TimeLocation *time.Location
+	// StackPolicy что сохранять о стеке для каждого уровня, для уровней без настройки StackFrames
+	StackPolicy map[int]StackMode
+
+	// Overflow поведение при переполнении очереди логгера и очередей драйверов без своего DriverConfig.Overflow.
+	// По умолчанию очередь логгера ждет, а очереди драйверов ждут не дольше OverflowTimeout, см. OverflowDefault.
+	// DropNewest, DropOldest и DropBelowLevel требуют Buffer > 0
+	Overflow OverflowPolicy
+	// OverflowTimeout время ожидания для BlockWithTimeout, по умолчанию 100ms
+	OverflowTimeout time.Duration
+	// OverflowLevel для DropBelowLevel: сообщения с уровнем ниже (LOG, DEBUG, ... при ERROR) отбрасываются
+	OverflowLevel int
+
+	// ErrorHandler обработчик ошибок драйверов и процессоров, по умолчанию пишет ошибку в stderr
+	ErrorHandler ErrorHandler
+}
+
+// DriverConfig настройки собственной очереди драйвера.
+// Драйверы из Output получают настройки по умолчанию.
+type DriverConfig struct {
+	Driver LogDriver
+	// Buffer размер очереди драйвера, по умолчанию LoggerConfig.Buffer, а если он 0, то 1000
+	Buffer int
+	// Workers количество горутин, вызывающих PutMsg, по умолчанию 1.
+	// При Workers > 1 порядок сообщений в драйвере не гарантируется
+	Workers int
+	// Retries количество повторных вызовов PutMsg после ошибки
+	Retries int
+	// RetryBackoff пауза перед первым повтором, дальше удваивается, по умолчанию 100ms
+	RetryBackoff time.Duration
+	// MaxRetryBackoff максимальная пауза между повторами, по умолчанию 5s
+	MaxRetryBackoff time.Duration
+	// Fallback получает сообщения, которые не удалось доставить основным драйвером
+	Fallback LogDriver
+	// Overflow поведение при переполнении очереди драйвера, по умолчанию LoggerConfig.Overflow.
+	// OverflowTimeout и OverflowLevel используются только вместе с ним
+	Overflow        OverflowPolicy
+	OverflowTimeout time.Duration
+	OverflowLevel   int
 }
 
 type LogDriver interface {
@@ -26,6 +89,31 @@ type LogDriver interface {
 	Init() error
 }
 
+// Flusher драйвер с собственной буферизацией, вызывается из Logger.Flush и Logger.Shutdown
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Closer драйвер, которому нужно освободить ресурсы, вызывается из Logger.Shutdown после Flush
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// ErrorReporter драйвер, который доставляет сообщения уже после возврата из PutMsg, например пачками.
+// Логгер вызывает SetErrorHandler до Init и передает обработчик, который отправляет недоставленное сообщение
+// в Fallback драйвера и ошибку в LoggerConfig.ErrorHandler, как для ошибок PutMsg
+type ErrorReporter interface {
+	SetErrorHandler(h ErrorHandler)
+}
+
+// ErrorHandler вызывается, когда драйвер не смог доставить сообщение, тогда err имеет тип *DriverError,
+// или когда процессор из LoggerConfig.Processors паниковал, тогда err имеет тип *ProcessorError
+type ErrorHandler func(msg Message, err error)
+
+var defaultErrorHandler = func(msg Message, err error) {
+	log.Println(err)
+}
+
 type NeedToLogDeterminant func(ctx context.Context, configuredLevel, level int) bool
 
 var defaultNeedToLogDeterminant = func(ctx context.Context, configuredLevel, level int) bool {
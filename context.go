@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type ctxKey int
+
+const (
+	tagsCtxKey ctxKey = iota
+	userCtxKey
+	eventCtxKey
+)
+
+// defaultEvent событие по умолчанию для FromContext, хранит *LogEvent
+var defaultEvent atomic.Value
+
+// userForLogStringKey строковый ключ пользователя для совместимости со старым способом передачи через контекст
+const userForLogStringKey = "userForLog"
+
+// ContextWithTag возвращает контекст с тегом, который логгер добавит к сообщениям
+func ContextWithTag(ctx context.Context, key, value string) context.Context {
+	return ContextWithTags(ctx, map[string]string{key: value})
+}
+
+// ContextWithTags возвращает контекст с тегами, которые дополняют теги родительского контекста
+func ContextWithTags(ctx context.Context, tags map[string]string) context.Context {
+	parent := tagsFromContext(ctx)
+	res := make(map[string]string, len(parent)+len(tags))
+	for k, v := range parent {
+		res[k] = v
+	}
+	for k, v := range tags {
+		res[k] = v
+	}
+
+	return context.WithValue(ctx, tagsCtxKey, res)
+}
+
+// TagsFromContext копия тегов, добавленных через ContextWithTag и ContextWithTags
+func TagsFromContext(ctx context.Context) map[string]string {
+	tags := tagsFromContext(ctx)
+	res := make(map[string]string, len(tags))
+	for k, v := range tags {
+		res[k] = v
+	}
+
+	return res
+}
+
+func tagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsCtxKey).(map[string]string)
+	return tags
+}
+
+// ContextWithUser возвращает контекст с пользователем для сообщений
+func ContextWithUser(ctx context.Context, user *UserForLog) context.Context {
+	return context.WithValue(ctx, userCtxKey, user)
+}
+
+// UserFromContext пользователь, добавленный через ContextWithUser
+func UserFromContext(ctx context.Context) *UserForLog {
+	user, _ := ctx.Value(userCtxKey).(*UserForLog)
+	return user
+}
+
+func (l *Logger) extractUserFromCtx(ctx context.Context) *UserForLog {
+	if user := UserFromContext(ctx); user != nil {
+		return user
+	}
+
+	if l.Config.DisableStringContextKeys {
+		return nil
+	}
+
+	user, _ := ctx.Value(userForLogStringKey).(*UserForLog)
+	return user
+}
+
+// NewContext возвращает контекст с событием логгера, которое потом достается через FromContext.
+// Для обогащения используется With: logger.NewContext(ctx, logger.FromContext(ctx).With(...))
+func NewContext(ctx context.Context, ev *LogEvent) context.Context {
+	return context.WithValue(ctx, eventCtxKey, ev)
+}
+
+// FromContext копия события логгера из контекста со всеми добавленными выше тегами и экстрой,
+// WithTag и другие методы не меняют событие в контексте.
+// Если в контексте его нет, возвращается событие, заданное SetDefault, а без него событие, которое ничего не пишет
+func FromContext(ctx context.Context) *LogEvent {
+	if ev := eventFromContext(ctx); ev != nil {
+		return ev.clone()
+	}
+
+	return &LogEvent{}
+}
+
+// SetDefault задает событие, которое FromContext возвращает для контекстов без логгера
+func SetDefault(ev *LogEvent) {
+	defaultEvent.Store(ev)
+}
+
+func eventFromContext(ctx context.Context) *LogEvent {
+	if ev, ok := ctx.Value(eventCtxKey).(*LogEvent); ok && ev != nil {
+		return ev
+	}
+
+	ev, _ := defaultEvent.Load().(*LogEvent)
+	return ev
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestFromContextReturnsCopy(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+
+	base := l.NewLogEvent().With(Tag("service", "api"))
+	ctx := NewContext(context.Background(), base)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			FromContext(ctx).WithTag("order", strconv.Itoa(i)).Error(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+	shutdown(t, l)
+
+	if _, ok := base.GetTags()["order"]; ok {
+		t.Errorf("event in context was changed: %v", base.GetTags())
+	}
+
+	msgs := d.messages()
+	if len(msgs) != 20 {
+		t.Fatalf("got %d messages, want 20", len(msgs))
+	}
+
+	for _, m := range msgs {
+		if m.Tags["order"] != strconv.Itoa(m.Data.(int)) || m.Tags["service"] != "api" {
+			t.Errorf("message %v has tags %v", m.Data, m.Tags)
+		}
+	}
+}
+
+func TestLogSnapshotsEvent(t *testing.T) {
+	d := &memDriver{block: make(chan struct{})}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}, Buffer: 10})
+
+	ev := l.NewLogEvent().WithTag("step", "1").WithUser(&UserForLog{ID: "1"})
+	ev.Log(context.Background(), "first")
+	ev.WithTag("step", "2").GetUser().ID = "2"
+	ev.Log(context.Background(), "second")
+
+	close(d.block)
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+
+	if msgs[0].Tags["step"] != "1" || msgs[0].User.ID != "1" {
+		t.Errorf("first message changed after the call: tags %v, user %+v", msgs[0].Tags, msgs[0].User)
+	}
+
+	if msgs[1].Tags["step"] != "2" || msgs[1].User.ID != "2" {
+		t.Errorf("second message: tags %v, user %+v", msgs[1].Tags, msgs[1].User)
+	}
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	ev := FromContext(context.Background())
+	ev.WithTag("a", "b").Error(context.Background(), "nothing")
+}
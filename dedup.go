@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// dedup подавляет одинаковые сообщения в пределах окна и после окна выдает одно сообщение-сводку.
+// Работает в горутине, которая раскладывает сообщения по драйверам, поэтому без блокировок
+type dedup struct {
+	window     time.Duration
+	entries    map[uint64]*dedupEntry
+	suppressed *levelCounter
+	formatTime func(time.Time) string
+}
+
+type dedupEntry struct {
+	first     messages
+	count     uint64
+	lastSeen  time.Time
+	windowEnd time.Time
+}
+
+func newDedup(window time.Duration, formatTime func(time.Time) string) *dedup {
+	if window <= 0 {
+		return nil
+	}
+
+	return &dedup{
+		window:     window,
+		entries:    make(map[uint64]*dedupEntry),
+		suppressed: &levelCounter{},
+		formatTime: formatTime,
+	}
+}
+
+// interval период проверки закончившихся окон
+func (d *dedup) interval() time.Duration {
+	if d.window < 2*time.Millisecond {
+		return time.Millisecond
+	}
+
+	return d.window / 2
+}
+
+// check возвращает false, если сообщение повторяет уже пропущенное в текущем окне.
+// Если окно того же сообщения уже закончилось, а expire до него еще не дошел,
+// возвращает сводку по этому окну, ее нужно отправить раньше сообщения
+func (d *dedup) check(m messages) (bool, []messages) {
+	fp := fingerprint(m)
+	now := time.Now()
+
+	e, ok := d.entries[fp]
+	if ok && now.Before(e.windowEnd) {
+		e.count++
+		e.lastSeen = m.Msg.Timestamp
+		d.suppressed.inc(m.Level)
+		return false, nil
+	}
+
+	var summary []messages
+	if ok && e.count > 0 {
+		summary = append(summary, d.summary(e))
+	}
+
+	d.entries[fp] = &dedupEntry{
+		first:     m,
+		lastSeen:  m.Msg.Timestamp,
+		windowEnd: now.Add(d.window),
+	}
+
+	return true, summary
+}
+
+// expire сводки по окнам, которые закончились к now, при force по всем окнам
+func (d *dedup) expire(now time.Time, force bool) []messages {
+	var res []messages
+	for fp, e := range d.entries {
+		if !force && now.Before(e.windowEnd) {
+			continue
+		}
+
+		delete(d.entries, fp)
+		if e.count > 0 {
+			res = append(res, d.summary(e))
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Msg.Seq < res[j].Msg.Seq
+	})
+
+	return res
+}
+
+// summary первое сообщение с текстом "repeated N times in T", временем первого и последнего повтора,
+// датой сводки считается последний повтор
+func (d *dedup) summary(e *dedupEntry) messages {
+	m := e.first
+	firstSeen := m.Msg.Timestamp
+
+	m.Msg.Data = fmt.Sprintf("%s (repeated %d times in %s)", DataText(m.Msg.Data), e.count, e.lastSeen.Sub(firstSeen))
+	m.Msg.Fields = append(m.Msg.Fields[:len(m.Msg.Fields):len(m.Msg.Fields)],
+		Uint64("repeat_count", e.count),
+		Time("first_seen", firstSeen),
+		Time("last_seen", e.lastSeen),
+	)
+	m.Msg.Timestamp = e.lastSeen
+	m.Msg.Time = d.formatTime(e.lastSeen)
+
+	return m
+}
+
+// fingerprint уровень, имя логгера, текст, теги и место вызова
+func fingerprint(m messages) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strconv.Itoa(m.Level)))
+	h.Write([]byte{0})
+	h.Write([]byte(m.Msg.Logger))
+	h.Write([]byte{0})
+	h.Write([]byte(DataText(m.Msg.Data)))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatUint(uint64(m.caller), 16)))
+
+	keys := make([]string, 0, len(m.Msg.Tags))
+	for k := range m.Msg.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		h.Write([]byte{0})
+		h.Write([]byte(k))
+		h.Write([]byte{'='})
+		h.Write([]byte(m.Msg.Tags[k]))
+	}
+
+	return h.Sum64()
+}
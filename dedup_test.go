@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupSummaryCountsEveryRepeat(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}, DedupWindow: 20 * time.Millisecond})
+	ev := l.NewLogEvent()
+	ctx := context.Background()
+
+	const total = 300
+	for i := 0; i < total; i++ {
+		ev.Error(ctx, "boom")
+		time.Sleep(time.Millisecond / 2)
+	}
+	shutdown(t, l)
+
+	var passed, repeated uint64
+	for _, m := range d.messages() {
+		n, ok := m.Fields.Map()["repeat_count"]
+		if !ok {
+			passed++
+			continue
+		}
+
+		repeated += n.(uint64)
+		if !strings.HasPrefix(m.Data.(string), "boom (repeated ") {
+			t.Errorf("unexpected summary text %q", m.Data)
+		}
+	}
+
+	suppressed := l.Stats().Suppressed["ERROR"]
+	if repeated != suppressed {
+		t.Errorf("summaries report %d repeats, Stats().Suppressed = %d", repeated, suppressed)
+	}
+
+	if passed+repeated != total {
+		t.Errorf("passed %d + repeated %d != %d logged", passed, repeated, total)
+	}
+
+	if passed < 2 {
+		t.Errorf("passed %d messages, want one per window", passed)
+	}
+}
+
+func TestDedupKeepsDifferentMessages(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}, DedupWindow: time.Hour})
+	ev := l.NewLogEvent()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		ev.Error(ctx, "a")
+		ev.Error(ctx, "b")
+		ev.With(Tag("k", "v")).Error(ctx, "a")
+		ev.Log(ctx, "a")
+	}
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 8 {
+		t.Fatalf("got %d messages, want 4 first + 4 summaries", len(msgs))
+	}
+
+	for _, m := range msgs[4:] {
+		if got := m.Fields.Map()["repeat_count"]; got != uint64(2) {
+			t.Errorf("summary %q has repeat_count %v, want 2", m.Data, got)
+		}
+	}
+}
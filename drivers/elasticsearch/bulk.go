@@ -0,0 +1,156 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/d-kolpakov/logger/v2/drivers/internal/batch"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ItemError элемент _bulk, который Elasticsearch не принял
+type ItemError struct {
+	Index  string
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("elasticsearch: index %s: status %d: %s: %s", e.Index, e.Status, e.Type, e.Reason)
+}
+
+// StatusError ответ _bulk с кодом не 2xx
+type StatusError = batch.StatusError
+
+type bulkResponse struct {
+	Errors bool                            `json:"errors"`
+	Items  []map[string]bulkResponseResult `json:"items"`
+}
+
+type bulkResponseResult struct {
+	Index  string `json:"_index"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+// bulk один запрос _bulk. Возвращает элементы, отклоненные с 429, которые стоит повторить;
+// остальные отклоненные элементы сразу уходят в ErrorHandler
+func (e *ElasticsearchDriver) bulk(ctx context.Context, items []batch.Item) ([]batch.Item, error) {
+	body, err := e.body(items)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, e.URL+"/_bulk", body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range e.Headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if e.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+e.APIKey)
+	} else if e.Username != "" {
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &StatusError{Op: "elasticsearch: bulk request", Status: resp.StatusCode, Body: string(b)}
+	}
+
+	var res bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+
+	if !res.Errors {
+		return nil, nil
+	}
+
+	var retry []batch.Item
+	for i, result := range res.Items {
+		if i >= len(items) {
+			break
+		}
+
+		for _, r := range result {
+			if r.Status < 300 {
+				continue
+			}
+
+			itemErr := &ItemError{Index: r.Index, Status: r.Status}
+			if r.Error != nil {
+				itemErr.Type = r.Error.Type
+				itemErr.Reason = r.Error.Reason
+			}
+
+			if r.Status == http.StatusTooManyRequests {
+				it := items[i]
+				it.Err = itemErr
+				retry = append(retry, it)
+				continue
+			}
+
+			e.ErrorHandler(items[i].Msg, itemErr)
+		}
+	}
+
+	return retry, nil
+}
+
+func (e *ElasticsearchDriver) body(items []batch.Item) (io.Reader, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+
+	var gz *gzip.Writer
+	if e.Gzip {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+
+	for _, it := range items {
+		d := it.Data.(document)
+		action, err := json.Marshal(map[string]map[string]string{e.OpType: {"_index": d.index}})
+		if err != nil {
+			return nil, err
+		}
+
+		w.Write(action)
+		w.Write([]byte{'\n'})
+		w.Write(d.doc)
+		w.Write([]byte{'\n'})
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &buf, nil
+}
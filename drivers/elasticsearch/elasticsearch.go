@@ -0,0 +1,216 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"github.com/d-kolpakov/logger/v2"
+	"github.com/d-kolpakov/logger/v2/drivers/internal/batch"
+	"github.com/d-kolpakov/logger/v2/drivers/stdout"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIndex           = "logs-{date}"
+	defaultIndexDateFormat = "2006.01.02"
+	defaultBatchSize       = 500
+	defaultBatchBytes      = 5 * 1024 * 1024
+	defaultFlushInterval   = time.Second
+	defaultRetries         = 3
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultMaxRetryBackoff = 30 * time.Second
+	defaultTimeout         = 30 * time.Second
+)
+
+var errClosed = errors.New("elasticsearch driver is closed")
+
+// ElasticsearchDriver отправляет сообщения в Elasticsearch или OpenSearch пачками через _bulk.
+// Документ тот же JSON, что пишет stdout.STDOUTDriver, с полем @timestamp.
+// Пачка уходит по BatchSize, BatchBytes или раз в FlushInterval, при 429 и 5xx запрос повторяется.
+// Элементы, которые не удалось записать, передаются в ErrorHandler по одному с *ItemError
+type ElasticsearchDriver struct {
+	// URL адрес кластера, например http://localhost:9200
+	URL    string
+	Client *http.Client
+	// Username и Password для basic auth
+	Username string
+	Password string
+	// APIKey заголовок Authorization: ApiKey, если задан
+	APIKey  string
+	Headers http.Header
+	// Index шаблон имени индекса, {date} заменяется на дату сообщения в UTC в формате IndexDateFormat,
+	// {service} на ServiceName. По умолчанию logs-{date}
+	Index           string
+	IndexDateFormat string
+	// OpType index или create, для data streams нужен create
+	OpType        string
+	BatchSize     int
+	BatchBytes    int
+	FlushInterval time.Duration
+	Gzip          bool
+	// Retries повторы запроса при ошибке сети, 429 и 5xx, и элементов, отклоненных с 429.
+	// По умолчанию 3, отрицательное значение отключает повторы
+	Retries         int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Timeout на один запрос _bulk
+	Timeout time.Duration
+	// ErrorHandler получает каждое недоставленное сообщение. По умолчанию сообщение уходит в Fallback драйвера,
+	// а ошибка в LoggerConfig.ErrorHandler логгера, драйвер без логгера пишет ошибку в stderr
+	ErrorHandler logger.ErrorHandler
+	LogRequest   map[string]struct{}
+	LogTrace     map[string]struct{}
+
+	formatter    stdout.STDOUTDriver
+	loggerErrors logger.ErrorHandler
+	batcher      *batch.Batcher
+}
+
+// document данные элемента пачки
+type document struct {
+	index string
+	doc   []byte
+}
+
+func (e *ElasticsearchDriver) Init() error {
+	if e.URL == "" {
+		return errors.New("elasticsearch: URL is required")
+	}
+	e.URL = strings.TrimRight(e.URL, "/")
+
+	if e.Client == nil {
+		e.Client = http.DefaultClient
+	}
+
+	if e.Index == "" {
+		e.Index = defaultIndex
+	}
+
+	if e.IndexDateFormat == "" {
+		e.IndexDateFormat = defaultIndexDateFormat
+	}
+
+	switch e.OpType {
+	case "":
+		e.OpType = "index"
+	case "index", "create":
+	default:
+		return errors.New("elasticsearch: OpType must be index or create")
+	}
+
+	if e.BatchSize <= 0 {
+		e.BatchSize = defaultBatchSize
+	}
+
+	if e.BatchBytes <= 0 {
+		e.BatchBytes = defaultBatchBytes
+	}
+
+	if e.FlushInterval <= 0 {
+		e.FlushInterval = defaultFlushInterval
+	}
+
+	if e.Retries < 0 {
+		e.Retries = 0
+	} else if e.Retries == 0 {
+		e.Retries = defaultRetries
+	}
+
+	if e.RetryBackoff <= 0 {
+		e.RetryBackoff = defaultRetryBackoff
+	}
+
+	if e.MaxRetryBackoff <= 0 {
+		e.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+
+	if e.Timeout <= 0 {
+		e.Timeout = defaultTimeout
+	}
+
+	if e.ErrorHandler == nil {
+		e.ErrorHandler = e.loggerErrors
+	}
+
+	if e.ErrorHandler == nil {
+		e.ErrorHandler = func(msg logger.Message, err error) {
+			log.Println(err)
+		}
+	}
+
+	e.formatter = stdout.STDOUTDriver{LogRequest: e.LogRequest, LogTrace: e.LogTrace}
+	e.batcher = batch.New(batch.Config{
+		BatchSize:       e.BatchSize,
+		BatchBytes:      e.BatchBytes,
+		FlushInterval:   e.FlushInterval,
+		Retries:         e.Retries,
+		RetryBackoff:    e.RetryBackoff,
+		MaxRetryBackoff: e.MaxRetryBackoff,
+		ErrorHandler:    e.ErrorHandler,
+		Send:            e.bulk,
+	})
+
+	return nil
+}
+
+// SetErrorHandler вызывается логгером, см. logger.ErrorReporter
+func (e *ElasticsearchDriver) SetErrorHandler(h logger.ErrorHandler) {
+	e.loggerErrors = h
+}
+
+func (e *ElasticsearchDriver) PutMsg(msg logger.Message) error {
+	doc, err := e.formatter.Format(msg)
+	if err != nil {
+		return err
+	}
+
+	ts := msg.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	doc = withTimestamp(doc, ts)
+
+	index := e.indexName(msg, ts)
+	it := batch.Item{Msg: msg, Size: len(doc) + len(index), Data: document{index: index, doc: doc}}
+	if !e.batcher.Add(it) {
+		return errClosed
+	}
+
+	return nil
+}
+
+// Flush отправляет накопленную пачку
+func (e *ElasticsearchDriver) Flush(ctx context.Context) error {
+	return e.batcher.Flush(ctx)
+}
+
+// Close останавливает периодическую отправку и отправляет остаток
+func (e *ElasticsearchDriver) Close(ctx context.Context) error {
+	return e.batcher.Close(ctx)
+}
+
+func (e *ElasticsearchDriver) indexName(msg logger.Message, ts time.Time) string {
+	name := strings.Replace(e.Index, "{date}", ts.UTC().Format(e.IndexDateFormat), -1)
+	name = strings.Replace(name, "{service}", strings.ToLower(msg.ServiceName), -1)
+
+	return name
+}
+
+// withTimestamp добавляет @timestamp первым полем документа
+func withTimestamp(doc []byte, ts time.Time) []byte {
+	if len(doc) < 2 || doc[0] != '{' {
+		return doc
+	}
+
+	res := make([]byte, 0, len(doc)+48)
+	res = append(res, `{"@timestamp":"`...)
+	res = ts.UTC().AppendFormat(res, time.RFC3339Nano)
+	res = append(res, '"')
+	if doc[1] != '}' {
+		res = append(res, ',')
+	}
+
+	return append(res, doc[1:]...)
+}
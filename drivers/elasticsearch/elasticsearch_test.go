@@ -0,0 +1,262 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/d-kolpakov/logger/v2"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bulkServer запоминает запросы _bulk, ответы задает respond по номеру запроса
+type bulkServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests [][]string
+	gzipped  []bool
+	respond  func(n int, docs []string, w http.ResponseWriter)
+}
+
+func newBulkServer(t *testing.T, respond func(n int, docs []string, w http.ResponseWriter)) *bulkServer {
+	s := &bulkServer{respond: respond}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		var body io.Reader = r.Body
+		gzipped := r.Header.Get("Content-Encoding") == "gzip"
+		if gzipped {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("bad gzip body: %v", err)
+				return
+			}
+			body = gz
+		}
+
+		var lines []string
+		sc := bufio.NewScanner(body)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+
+		// пары строк: действие и документ
+		var docs []string
+		for i := 0; i+1 < len(lines); i += 2 {
+			docs = append(docs, lines[i]+"\n"+lines[i+1])
+		}
+
+		s.mu.Lock()
+		n := len(s.requests)
+		s.requests = append(s.requests, docs)
+		s.gzipped = append(s.gzipped, gzipped)
+		s.mu.Unlock()
+
+		if s.respond != nil {
+			s.respond(n, docs, w)
+			return
+		}
+
+		io.WriteString(w, `{"errors":false,"items":[]}`)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *bulkServer) batches() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]string(nil), s.requests...)
+}
+
+type recordedErrors struct {
+	mu   sync.Mutex
+	msgs []logger.Message
+	errs []error
+}
+
+func (r *recordedErrors) handle(msg logger.Message, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.msgs = append(r.msgs, msg)
+	r.errs = append(r.errs, err)
+}
+
+func (r *recordedErrors) get() ([]logger.Message, []error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]logger.Message(nil), r.msgs...), append([]error(nil), r.errs...)
+}
+
+func newDriver(t *testing.T, e *ElasticsearchDriver) *ElasticsearchDriver {
+	t.Helper()
+
+	if e.FlushInterval == 0 {
+		e.FlushInterval = time.Hour
+	}
+
+	if e.RetryBackoff == 0 {
+		e.RetryBackoff = time.Millisecond
+	}
+
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Close(context.Background()) })
+
+	return e
+}
+
+func put(t *testing.T, e *ElasticsearchDriver, data ...string) {
+	t.Helper()
+
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, d := range data {
+		if err := e.PutMsg(logger.Message{MessageType: "LOG", ServiceName: "svc", Timestamp: ts, Data: d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBatchSizeAndGzip(t *testing.T) {
+	s := newBulkServer(t, nil)
+	e := newDriver(t, &ElasticsearchDriver{URL: s.URL, BatchSize: 3, Gzip: true, Index: "logs-{service}-{date}"})
+
+	put(t, e, "m0", "m1", "m2", "m3", "m4", "m5", "m6")
+
+	// полные пачки уходят сразу из PutMsg
+	if got := s.batches(); len(got) != 2 || len(got[0]) != 3 || len(got[1]) != 3 {
+		t.Fatalf("got batches %v, want two of 3", got)
+	}
+
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.batches()
+	if len(got) != 3 || len(got[2]) != 1 {
+		t.Fatalf("got batches %v, want 3, 3 and 1", got)
+	}
+
+	for _, gz := range s.gzipped {
+		if !gz {
+			t.Error("request body is not gzipped")
+		}
+	}
+
+	doc := got[0][0]
+	if !strings.HasPrefix(doc, `{"index":{"_index":"logs-svc-2024.03.01"}}`+"\n"+`{"@timestamp":"2024-03-01T12:00:00Z",`) {
+		t.Errorf("unexpected document %s", doc)
+	}
+
+	if !strings.Contains(doc, `"m0"`) || !strings.Contains(got[2][0], `"m6"`) {
+		t.Errorf("messages out of order: %v", got)
+	}
+}
+
+func TestItemErrors(t *testing.T) {
+	s := newBulkServer(t, func(n int, docs []string, w http.ResponseWriter) {
+		if n > 0 {
+			io.WriteString(w, `{"errors":false,"items":[{"index":{"_index":"logs","status":201}}]}`)
+			return
+		}
+
+		// m0 принят, m1 отклонен насовсем, m2 отклонен с 429 и повторяется
+		fmt.Fprint(w, `{"errors":true,"items":[`+
+			`{"index":{"_index":"logs","status":201}},`+
+			`{"index":{"_index":"logs","status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}},`+
+			`{"index":{"_index":"logs","status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}}]}`)
+	})
+	rec := &recordedErrors{}
+	e := newDriver(t, &ElasticsearchDriver{URL: s.URL, Index: "logs", ErrorHandler: rec.handle})
+
+	put(t, e, "m0", "m1", "m2")
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.batches()
+	if len(got) != 2 || len(got[1]) != 1 || !strings.Contains(got[1][0], `"m2"`) {
+		t.Fatalf("want the 429 item retried alone, got %v", got)
+	}
+
+	msgs, errs := rec.get()
+	if len(msgs) != 1 || msgs[0].Data != "m1" {
+		t.Fatalf("ErrorHandler got %v, want only m1", msgs)
+	}
+
+	var ie *ItemError
+	if !errors.As(errs[0], &ie) || ie.Status != 400 || ie.Type != "mapper_parsing_exception" || ie.Reason != "bad field" {
+		t.Errorf("unexpected item error %v", errs[0])
+	}
+}
+
+func TestFailuresGoToLoggerFallback(t *testing.T) {
+	s := newBulkServer(t, func(n int, docs []string, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	fallback := &memDriver{}
+	rec := &recordedErrors{}
+	e := &ElasticsearchDriver{URL: s.URL, FlushInterval: time.Hour}
+
+	l, err := logger.GetLogger(logger.LoggerConfig{
+		Level:        logger.TRACE,
+		Drivers:      []logger.DriverConfig{{Driver: e, Fallback: fallback}},
+		ErrorHandler: rec.handle,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.NewLogEvent().Log(context.Background(), "m0")
+	l.Shutdown(context.Background())
+
+	if n := fallback.count(); n != 1 {
+		t.Errorf("fallback got %d messages, want 1", n)
+	}
+
+	_, errs := rec.get()
+	var de *logger.DriverError
+	if len(errs) != 1 || !errors.As(errs[0], &de) || de.Driver != e {
+		t.Errorf("logger ErrorHandler got %v, want *DriverError from the driver", errs)
+	}
+}
+
+type memDriver struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (d *memDriver) Init() error {
+	return nil
+}
+
+func (d *memDriver) PutMsg(msg logger.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.n++
+
+	return nil
+}
+
+func (d *memDriver) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.n
+}
@@ -0,0 +1,281 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/d-kolpakov/logger/v2"
+	"github.com/d-kolpakov/logger/v2/drivers/stdout"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultBufferSize   = 64 * 1024
+	defaultSyncInterval = time.Second
+	defaultFileMode     = 0644
+)
+
+var errClosed = errors.New("file driver is closed")
+
+// FileDriver пишет сообщения в файл в том же JSON, что и stdout.STDOUTDriver, по одному на строку.
+// Файл ротируется по размеру и по времени, старые файлы сжимаются и удаляются по количеству и возрасту.
+// По SIGHUP файл открывается заново, чтобы работать с внешним logrotate
+type FileDriver struct {
+	// Filename путь к файлу, каталог создается при Init
+	Filename string
+	// MaxSize размер файла в байтах, после которого он ротируется, 0 отключает
+	MaxSize int64
+	// RotateEvery период ротации, отсчитывается от начала эпохи в UTC (24h ротирует в полночь UTC), 0 отключает
+	RotateEvery time.Duration
+	// Compress сжимать ротированные файлы в gzip
+	Compress bool
+	// MaxBackups сколько ротированных файлов хранить, 0 хранит все
+	MaxBackups int
+	// MaxAge сколько хранить ротированные файлы, 0 хранит без ограничения
+	MaxAge time.Duration
+	// BufferSize размер буфера записи, по умолчанию 64KB
+	BufferSize int
+	// SyncInterval как часто сбрасывать буфер и делать fsync, по умолчанию раз в секунду
+	SyncInterval time.Duration
+	// FileMode права на новые файлы, по умолчанию 0644
+	FileMode os.FileMode
+	// DisableSIGHUP не переоткрывать файл по SIGHUP
+	DisableSIGHUP bool
+	LogRequest    map[string]struct{}
+	LogTrace      map[string]struct{}
+
+	formatter  stdout.STDOUTDriver
+	mu         sync.Mutex
+	file       *os.File
+	w          *bufio.Writer
+	size       int64
+	nextRotate time.Time
+	closed     bool
+
+	mill     chan struct{}
+	sighup   chan os.Signal
+	done     chan struct{}
+	wg       sync.WaitGroup
+	millDone chan struct{}
+}
+
+func (f *FileDriver) Init() error {
+	if f.Filename == "" {
+		return errors.New("file driver: Filename is required")
+	}
+
+	if f.BufferSize <= 0 {
+		f.BufferSize = defaultBufferSize
+	}
+
+	if f.SyncInterval <= 0 {
+		f.SyncInterval = defaultSyncInterval
+	}
+
+	if f.FileMode == 0 {
+		f.FileMode = defaultFileMode
+	}
+
+	f.formatter = stdout.STDOUTDriver{LogRequest: f.LogRequest, LogTrace: f.LogTrace}
+
+	if err := os.MkdirAll(filepath.Dir(f.Filename), 0755); err != nil {
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.done = make(chan struct{})
+	f.mill = make(chan struct{}, 1)
+	f.millDone = make(chan struct{})
+
+	go f.runMill()
+	f.mill <- struct{}{}
+
+	f.wg.Add(1)
+	go f.syncLoop()
+
+	if !f.DisableSIGHUP {
+		f.sighup = make(chan os.Signal, 1)
+		signal.Notify(f.sighup, syscall.SIGHUP)
+
+		f.wg.Add(1)
+		go f.reopenLoop()
+	}
+
+	return nil
+}
+
+func (f *FileDriver) PutMsg(msg logger.Message) error {
+	line, err := f.formatter.Format(msg)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return errClosed
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	if f.needRotate(int64(len(line))) {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.w.Write(line)
+	f.size += int64(n)
+
+	return err
+}
+
+// Reopen закрывает и заново открывает файл по тому же пути, то же делает SIGHUP
+func (f *FileDriver) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return errClosed
+	}
+
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+// Flush сбрасывает буфер и делает fsync
+func (f *FileDriver) Flush(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sync()
+}
+
+// Close сбрасывает буфер, закрывает файл и ждет сжатия и удаления старых файлов
+func (f *FileDriver) Close(ctx context.Context) error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	err := f.closeFile()
+	f.mu.Unlock()
+
+	if f.sighup != nil {
+		signal.Stop(f.sighup)
+	}
+	close(f.done)
+	f.wg.Wait()
+
+	close(f.mill)
+	select {
+	case <-f.millDone:
+	case <-ctx.Done():
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+
+	return err
+}
+
+func (f *FileDriver) open() error {
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.FileMode)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.w = bufio.NewWriterSize(file, f.BufferSize)
+
+	if f.RotateEvery > 0 {
+		f.nextRotate = time.Now().Truncate(f.RotateEvery).Add(f.RotateEvery)
+	}
+
+	return nil
+}
+
+func (f *FileDriver) sync() error {
+	if f.file == nil {
+		return nil
+	}
+
+	if err := f.w.Flush(); err != nil {
+		return err
+	}
+
+	return f.file.Sync()
+}
+
+func (f *FileDriver) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.sync()
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	f.file = nil
+	f.w = nil
+
+	return err
+}
+
+func (f *FileDriver) syncLoop() {
+	defer f.wg.Done()
+
+	t := time.NewTicker(f.SyncInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			f.mu.Lock()
+			if !f.closed {
+				f.sync()
+			}
+			f.mu.Unlock()
+		case <-f.done:
+			return
+		}
+	}
+}
+
+func (f *FileDriver) reopenLoop() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.sighup:
+			f.Reopen()
+		case <-f.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/d-kolpakov/logger/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newFileDriver инициализирует f с файлом app.log во временном каталоге
+func newFileDriver(t *testing.T, f *FileDriver) *FileDriver {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "file-driver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	if f.Filename == "" {
+		f.Filename = filepath.Join(dir, "app.log")
+	}
+
+	if err := f.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close(context.Background()) })
+
+	return f
+}
+
+func put(t *testing.T, f *FileDriver, data ...string) {
+	t.Helper()
+
+	for _, d := range data {
+		if err := f.PutMsg(logger.Message{MessageType: "LOG", Data: d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func closeDriver(t *testing.T, f *FileDriver) {
+	t.Helper()
+
+	if err := f.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.HasSuffix(name, compressSuffix) {
+		gz, err := gzip.NewReader(strings.NewReader(string(data)))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if data, err = ioutil.ReadAll(gz); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	return string(data)
+}
+
+func TestRotateEvery(t *testing.T) {
+	f := newFileDriver(t, &FileDriver{RotateEvery: 50 * time.Millisecond, DisableSIGHUP: true})
+
+	put(t, f, "msg-0")
+	time.Sleep(time.Until(f.nextRotate) + 5*time.Millisecond)
+	put(t, f, "msg-1")
+	closeDriver(t, f)
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 1 {
+		t.Fatalf("got %d backups, want 1", len(backups))
+	}
+
+	if data := readFile(t, backups[0].path); !strings.Contains(data, "msg-0") || strings.Contains(data, "msg-1") {
+		t.Errorf("backup holds %s", data)
+	}
+
+	if data := readFile(t, f.Filename); !strings.Contains(data, "msg-1") || strings.Contains(data, "msg-0") {
+		t.Errorf("current file holds %s", data)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	f := newFileDriver(t, &FileDriver{MaxSize: 1, Compress: true, DisableSIGHUP: true})
+
+	put(t, f, "msg-0", "msg-1", "msg-2")
+	closeDriver(t, f)
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+
+	// сначала новые
+	for i, b := range backups {
+		if !b.compressed {
+			t.Errorf("%s is not compressed", b.path)
+			continue
+		}
+
+		if want := []string{"msg-1", "msg-0"}[i]; !strings.Contains(readFile(t, b.path), want) {
+			t.Errorf("%s does not hold %s", b.path, want)
+		}
+	}
+}
+
+func TestMaxBackups(t *testing.T) {
+	f := newFileDriver(t, &FileDriver{MaxSize: 1, MaxBackups: 2, DisableSIGHUP: true})
+
+	put(t, f, "msg-0", "msg-1", "msg-2", "msg-3", "msg-4")
+	closeDriver(t, f)
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+
+	for i, want := range []string{"msg-3", "msg-2"} {
+		if data := readFile(t, backups[i].path); !strings.Contains(data, want) {
+			t.Errorf("backup %d holds %s, want %s", i, data, want)
+		}
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-driver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := filepath.Join(dir, "app-"+time.Now().Add(-2*time.Hour).Format(backupTimeFormat)+".log.gz")
+	recent := filepath.Join(dir, "app-"+time.Now().Add(-time.Minute).Format(backupTimeFormat)+".log")
+	other := filepath.Join(dir, "other-"+time.Now().Add(-2*time.Hour).Format(backupTimeFormat)+".log")
+	for _, name := range []string{old, recent, other} {
+		if err := ioutil.WriteFile(name, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Init запускает очистку сразу
+	f := newFileDriver(t, &FileDriver{Filename: filepath.Join(dir, "app.log"), MaxAge: time.Hour, DisableSIGHUP: true})
+	closeDriver(t, f)
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("backup older than MaxAge was not removed: %v", err)
+	}
+
+	for _, name := range []string{recent, other} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("%s was removed: %v", filepath.Base(name), err)
+		}
+	}
+}
+
+// moveAway переименовывает файл драйвера, как это делает внешний logrotate
+func moveAway(t *testing.T, f *FileDriver) string {
+	t.Helper()
+
+	moved := f.Filename + ".1"
+	if err := os.Rename(f.Filename, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	return moved
+}
+
+func TestReopen(t *testing.T) {
+	f := newFileDriver(t, &FileDriver{DisableSIGHUP: true})
+
+	put(t, f, "msg-0")
+	moved := moveAway(t, f)
+	// до Reopen запись продолжается в переименованный файл
+	put(t, f, "msg-1")
+
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	put(t, f, "msg-2")
+	closeDriver(t, f)
+
+	if data := readFile(t, moved); !strings.Contains(data, "msg-0") || !strings.Contains(data, "msg-1") || strings.Contains(data, "msg-2") {
+		t.Errorf("moved file holds %s", data)
+	}
+
+	if data := readFile(t, f.Filename); !strings.Contains(data, "msg-2") || strings.Contains(data, "msg-1") {
+		t.Errorf("reopened file holds %s", data)
+	}
+
+	if err := f.Reopen(); err != errClosed {
+		t.Errorf("Reopen after Close returned %v, want errClosed", err)
+	}
+}
+
+func TestSIGHUPReopens(t *testing.T) {
+	f := newFileDriver(t, &FileDriver{})
+
+	put(t, f, "msg-0")
+	moveAway(t, f)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(f.Filename); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file was not reopened on SIGHUP")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	put(t, f, "msg-1")
+	closeDriver(t, f)
+
+	if data := readFile(t, f.Filename); !strings.Contains(data, "msg-1") || strings.Contains(data, "msg-0") {
+		t.Errorf("reopened file holds %s", data)
+	}
+}
@@ -0,0 +1,235 @@
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat время ротации в имени файла: app-2006-01-02T15-04-05.000.log.
+// Если файл с таким именем уже есть, перед расширением добавляется номер: app-2006-01-02T15-04-05.000.1.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const compressSuffix = ".gz"
+
+func (f *FileDriver) needRotate(n int64) bool {
+	if f.MaxSize > 0 && f.size > 0 && f.size+n > f.MaxSize {
+		return true
+	}
+
+	return f.RotateEvery > 0 && !time.Now().Before(f.nextRotate)
+}
+
+// rotate переименовывает текущий файл в резервный и открывает новый
+func (f *FileDriver) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Filename, f.backupName(time.Now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	select {
+	case f.mill <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// backupName имя резервного файла, которое не занято ни им, ни его сжатой копией
+func (f *FileDriver) backupName(t time.Time) string {
+	prefix, ext := f.prefixAndExt()
+	base := filepath.Join(filepath.Dir(f.Filename), prefix+t.Format(backupTimeFormat))
+
+	name := base + ext
+	for i := 1; exists(name) || exists(name+compressSuffix); i++ {
+		name = base + "." + strconv.Itoa(i) + ext
+	}
+
+	return name
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+func (f *FileDriver) prefixAndExt() (prefix, ext string) {
+	name := filepath.Base(f.Filename)
+	ext = filepath.Ext(name)
+
+	return strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// runMill сжимает и удаляет старые файлы в отдельной горутине, чтобы не задерживать запись
+func (f *FileDriver) runMill() {
+	defer close(f.millDone)
+
+	for range f.mill {
+		if err := f.millRun(); err != nil {
+			log.Println("file driver:", err)
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+	// seq номер файла среди ротированных в одну миллисекунду
+	seq        int
+	compressed bool
+}
+
+func (f *FileDriver) millRun() error {
+	if f.MaxBackups <= 0 && f.MaxAge <= 0 && !f.Compress {
+		return nil
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	var remove, compress []backupFile
+	cutoff := time.Now().Add(-f.MaxAge)
+	for i, b := range backups {
+		switch {
+		case f.MaxBackups > 0 && i >= f.MaxBackups:
+			remove = append(remove, b)
+		case f.MaxAge > 0 && b.time.Before(cutoff):
+			remove = append(remove, b)
+		case f.Compress && !b.compressed:
+			compress = append(compress, b)
+		}
+	}
+
+	for _, b := range remove {
+		if rerr := os.Remove(b.path); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+
+	for _, b := range compress {
+		if cerr := compressFile(b.path, b.path+compressSuffix, f.FileMode); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// backups ротированные файлы, сначала новые
+func (f *FileDriver) backups() ([]backupFile, error) {
+	files, err := ioutil.ReadDir(filepath.Dir(f.Filename))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, ext := f.prefixAndExt()
+	var res []backupFile
+	for _, fi := range files {
+		if fi.IsDir() {
+			continue
+		}
+
+		name := fi.Name()
+		compressed := strings.HasSuffix(name, ext+compressSuffix)
+		ts := strings.TrimPrefix(name, prefix)
+		if ts == name {
+			continue
+		}
+
+		if compressed {
+			ts = strings.TrimSuffix(ts, ext+compressSuffix)
+		} else {
+			ts = strings.TrimSuffix(ts, ext)
+		}
+
+		t, seq, ok := parseBackupTime(ts)
+		if !ok {
+			continue
+		}
+
+		res = append(res, backupFile{
+			path:       filepath.Join(filepath.Dir(f.Filename), name),
+			time:       t,
+			seq:        seq,
+			compressed: compressed,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].time.Equal(res[j].time) {
+			return res[i].time.After(res[j].time)
+		}
+
+		return res[i].seq > res[j].seq
+	})
+
+	return res, nil
+}
+
+// parseBackupTime разбирает время ротации и номер из имени файла без префикса и расширения
+func parseBackupTime(ts string) (time.Time, int, bool) {
+	seq := 0
+	if len(ts) > len(backupTimeFormat) {
+		if ts[len(backupTimeFormat)] != '.' {
+			return time.Time{}, 0, false
+		}
+
+		n, err := strconv.Atoi(ts[len(backupTimeFormat)+1:])
+		if err != nil || n <= 0 {
+			return time.Time{}, 0, false
+		}
+		seq = n
+		ts = ts[:len(backupTimeFormat)]
+	}
+
+	t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	return t, seq, true
+}
+
+func compressFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
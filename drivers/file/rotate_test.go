@@ -0,0 +1,80 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"github.com/d-kolpakov/logger/v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateKeepsEveryBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-driver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &FileDriver{Filename: filepath.Join(dir, "app.log"), MaxSize: 1, DisableSIGHUP: true}
+	if err := f.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// каждое сообщение после первого ротирует файл, обычно несколько раз за миллисекунду
+	for i := 0; i < 20; i++ {
+		if err := f.PutMsg(logger.Message{Data: fmt.Sprintf("msg-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 19 {
+		t.Fatalf("got %d backups, want 19", len(backups))
+	}
+
+	// сначала новые: последний ротированный файл содержит сообщение 18
+	data, err := ioutil.ReadFile(backups[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "msg-18") {
+		t.Errorf("newest backup %s holds %s", filepath.Base(backups[0].path), data)
+	}
+}
+
+func TestParseBackupTime(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 30, 45, 123e6, time.Local)
+
+	cases := []struct {
+		ts  string
+		seq int
+		ok  bool
+	}{
+		{"2024-03-01T12-30-45.123", 0, true},
+		{"2024-03-01T12-30-45.123.7", 7, true},
+		{"2024-03-01T12-30-45.123.0", 0, false},
+		{"2024-03-01T12-30-45.123.x", 0, false},
+		{"2024-03-01T12-30-45.123-1", 0, false},
+		{"current", 0, false},
+	}
+
+	for _, c := range cases {
+		ts, seq, ok := parseBackupTime(c.ts)
+		if ok != c.ok || seq != c.seq || (ok && !ts.Equal(want)) {
+			t.Errorf("parseBackupTime(%q) = %s, %d, %v", c.ts, ts, seq, ok)
+		}
+	}
+}
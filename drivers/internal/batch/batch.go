@@ -0,0 +1,226 @@
+// Package batch общая часть драйверов, которые копят сообщения и отправляют их пачками по HTTP:
+// накопление по размеру, периодическая отправка, повторы с паузой и передача недоставленных сообщений в ErrorHandler
+package batch
+
+import (
+	"context"
+	"fmt"
+	"github.com/d-kolpakov/logger/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Item элемент пачки
+type Item struct {
+	Msg logger.Message
+	// Size размер элемента для Config.BatchBytes
+	Size int
+	// Data данные драйвера, например готовый документ
+	Data interface{}
+	// Err последняя ошибка элемента, который сервер отклонил отдельно от остальных,
+	// с ней элемент попадает в ErrorHandler, если повторы не помогли
+	Err error
+}
+
+// Config настройки Batcher, значения по умолчанию задает драйвер
+type Config struct {
+	BatchSize     int
+	BatchBytes    int
+	FlushInterval time.Duration
+	// Retries сколько раз повторить пачку после первой попытки
+	Retries         int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	ErrorHandler    logger.ErrorHandler
+	// Prepare вызывается один раз для каждой пачки перед отправкой, пачки не отправляются параллельно
+	Prepare func(batch []Item)
+	// Send одна попытка отправки. Возвращает элементы, которые стоит повторить, если остальные приняты,
+	// или ошибку для всей пачки. Повторяются сетевые ошибки и *StatusError с 429 и 5xx
+	Send func(ctx context.Context, batch []Item) ([]Item, error)
+}
+
+// StatusError ответ сервера с кодом не 2xx
+type StatusError struct {
+	// Op что не удалось, например "loki: push"
+	Op     string
+	Status int
+	Body   string
+	// RetryAfter пауза из заголовка Retry-After, 0 если его нет
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed with status %d: %s", e.Op, e.Status, e.Body)
+}
+
+// Batcher копит элементы и отправляет их через Config.Send
+type Batcher struct {
+	config Config
+
+	mu     sync.Mutex
+	batch  []Item
+	size   int
+	closed bool
+	sendMu sync.Mutex
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New создает Batcher и запускает периодическую отправку
+func New(config Config) *Batcher {
+	b := &Batcher{config: config, done: make(chan struct{})}
+
+	b.wg.Add(1)
+	go b.flushLoop()
+
+	return b
+}
+
+// Add добавляет элемент и отправляет пачку, если она заполнилась. Возвращает false после Close
+func (b *Batcher) Add(it Item) bool {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return false
+	}
+
+	b.batch = append(b.batch, it)
+	b.size += it.Size
+	var full []Item
+	if len(b.batch) >= b.config.BatchSize || b.size >= b.config.BatchBytes {
+		full = b.take()
+	}
+	b.mu.Unlock()
+
+	if full != nil {
+		b.send(context.Background(), full)
+	}
+
+	return true
+}
+
+// Flush отправляет накопленную пачку
+func (b *Batcher) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+
+	return b.send(ctx, batch)
+}
+
+// Close останавливает периодическую отправку и отправляет остаток
+func (b *Batcher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	batch := b.take()
+	b.mu.Unlock()
+
+	close(b.done)
+	b.wg.Wait()
+
+	return b.send(ctx, batch)
+}
+
+// take забирает текущую пачку, вызывается под b.mu
+func (b *Batcher) take() []Item {
+	batch := b.batch
+	b.batch = nil
+	b.size = 0
+
+	return batch
+}
+
+func (b *Batcher) flushLoop() {
+	defer b.wg.Done()
+
+	t := time.NewTicker(b.config.FlushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			b.Flush(context.Background())
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// send отправляет пачку с повторами, недоставленные элементы уходят в ErrorHandler.
+// Возвращает ошибку, если пачку не удалось отправить после всех попыток
+func (b *Batcher) send(ctx context.Context, batch []Item) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	if b.config.Prepare != nil {
+		b.config.Prepare(batch)
+	}
+
+	backoff := b.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := b.config.Send(ctx, batch)
+		if err == nil && len(retry) == 0 {
+			return nil
+		}
+
+		var wait time.Duration
+		if err != nil {
+			se, ok := err.(*StatusError)
+			if ok && !retryable(se.Status) {
+				b.report(batch, err)
+				return err
+			}
+
+			if ok {
+				wait = se.RetryAfter
+			}
+			retry = batch
+		}
+
+		if attempt >= b.config.Retries {
+			b.report(retry, err)
+			return err
+		}
+		batch = retry
+
+		if wait <= 0 {
+			wait = backoff
+			backoff *= 2
+			if backoff > b.config.MaxRetryBackoff {
+				backoff = b.config.MaxRetryBackoff
+			}
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			b.report(batch, ctx.Err())
+			return ctx.Err()
+		}
+	}
+}
+
+// report передает элементы в ErrorHandler, у отклоненных по отдельности элементов своя ошибка
+func (b *Batcher) report(batch []Item, err error) {
+	for _, it := range batch {
+		if it.Err != nil {
+			b.config.ErrorHandler(it.Msg, it.Err)
+			continue
+		}
+
+		b.config.ErrorHandler(it.Msg, err)
+	}
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
@@ -0,0 +1,310 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"github.com/d-kolpakov/logger/v2"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder запоминает вызовы Send и ErrorHandler, ответы Send задает respond по номеру попытки
+type recorder struct {
+	mu       sync.Mutex
+	attempts [][]interface{}
+	reported []interface{}
+	errs     []error
+	respond  func(n int, batch []Item) ([]Item, error)
+}
+
+func (r *recorder) send(ctx context.Context, batch []Item) ([]Item, error) {
+	r.mu.Lock()
+	n := len(r.attempts)
+	r.attempts = append(r.attempts, data(batch))
+	r.mu.Unlock()
+
+	if r.respond == nil {
+		return nil, nil
+	}
+
+	return r.respond(n, batch)
+}
+
+func (r *recorder) handle(msg logger.Message, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reported = append(r.reported, msg.Data)
+	r.errs = append(r.errs, err)
+}
+
+func (r *recorder) sent() [][]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([][]interface{}(nil), r.attempts...)
+}
+
+func data(batch []Item) []interface{} {
+	res := make([]interface{}, 0, len(batch))
+	for _, it := range batch {
+		res = append(res, it.Msg.Data)
+	}
+
+	return res
+}
+
+func items(data ...string) []Item {
+	res := make([]Item, 0, len(data))
+	for _, d := range data {
+		res = append(res, Item{Msg: logger.Message{Data: d}, Size: len(d)})
+	}
+
+	return res
+}
+
+// newBatcher Batcher без периодической отправки, если она не задана
+func newBatcher(t *testing.T, r *recorder, config Config) *Batcher {
+	t.Helper()
+
+	if config.BatchSize == 0 {
+		config.BatchSize = 100
+	}
+	if config.BatchBytes == 0 {
+		config.BatchBytes = 1 << 20
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = time.Hour
+	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = time.Millisecond
+	}
+	if config.MaxRetryBackoff == 0 {
+		config.MaxRetryBackoff = time.Millisecond
+	}
+	config.Send = r.send
+	config.ErrorHandler = r.handle
+
+	b := New(config)
+	t.Cleanup(func() { b.Close(context.Background()) })
+
+	return b
+}
+
+func TestAddSendsFullBatch(t *testing.T) {
+	r := &recorder{}
+	b := newBatcher(t, r, Config{BatchSize: 2, BatchBytes: 5})
+
+	for _, it := range items("a", "b", "cdefg", "h") {
+		b.Add(it)
+	}
+
+	// по BatchSize, потом по BatchBytes
+	want := [][]interface{}{{"a", "b"}, {"cdefg"}}
+	if got := r.sent(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %v, want %v", got, want)
+	}
+
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.sent(); len(got) != 3 || !reflect.DeepEqual(got[2], []interface{}{"h"}) {
+		t.Errorf("Close did not send the rest: %v", got)
+	}
+
+	if b.Add(items("i")[0]) {
+		t.Error("Add accepted an item after Close")
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	r := &recorder{}
+	b := newBatcher(t, r, Config{FlushInterval: 10 * time.Millisecond})
+
+	b.Add(items("a")[0])
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.sent()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("batch was not sent by FlushInterval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPrepareOncePerBatch(t *testing.T) {
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		if n == 0 {
+			return nil, &StatusError{Status: http.StatusServiceUnavailable}
+		}
+		return nil, nil
+	}}
+
+	var prepared int
+	b := newBatcher(t, r, Config{Retries: 1, Prepare: func(batch []Item) { prepared++ }})
+
+	b.Add(items("a")[0])
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if prepared != 1 || len(r.sent()) != 2 {
+		t.Errorf("prepared %d times for %d attempts, want 1 for 2", prepared, len(r.sent()))
+	}
+}
+
+func TestSendRetriesRetryableStatus(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		if n < len(statuses) {
+			return nil, &StatusError{Status: statuses[n]}
+		}
+		return nil, nil
+	}}
+	b := newBatcher(t, r, Config{Retries: 3})
+
+	if err := b.send(context.Background(), items("a", "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]interface{}{{"a", "b"}, {"a", "b"}, {"a", "b"}}
+	if got := r.sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %v, want the whole batch 3 times", got)
+	}
+
+	if len(r.reported) != 0 {
+		t.Errorf("ErrorHandler got %v", r.reported)
+	}
+}
+
+func TestSendRetriesItems(t *testing.T) {
+	itemErr := errors.New("rejected")
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		if n == 0 {
+			retry := batch[1]
+			retry.Err = itemErr
+			return []Item{retry}, nil
+		}
+		return nil, nil
+	}}
+	b := newBatcher(t, r, Config{Retries: 1})
+
+	if err := b.send(context.Background(), items("a", "b", "c")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]interface{}{{"a", "b", "c"}, {"b"}}
+	if got := r.sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %v, want %v", got, want)
+	}
+
+	if len(r.reported) != 0 {
+		t.Errorf("ErrorHandler got %v", r.reported)
+	}
+}
+
+func TestSendItemRetriesExhausted(t *testing.T) {
+	itemErr := errors.New("rejected")
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		retry := batch[0]
+		retry.Err = itemErr
+		return []Item{retry}, nil
+	}}
+	b := newBatcher(t, r, Config{Retries: 2})
+
+	// элементы отклонены по отдельности, пачка в целом принята
+	if err := b.send(context.Background(), items("a", "b")); err != nil {
+		t.Fatalf("send returned %v, want nil", err)
+	}
+
+	if n := len(r.sent()); n != 3 {
+		t.Errorf("got %d attempts, want 3", n)
+	}
+
+	if !reflect.DeepEqual(r.reported, []interface{}{"a"}) || r.errs[0] != itemErr {
+		t.Errorf("ErrorHandler got %v %v, want a with the item error", r.reported, r.errs)
+	}
+}
+
+func TestSendRetriesExhausted(t *testing.T) {
+	netErr := errors.New("connection refused")
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		return nil, netErr
+	}}
+	b := newBatcher(t, r, Config{Retries: 2})
+
+	if err := b.send(context.Background(), items("a", "b")); err != netErr {
+		t.Fatalf("send returned %v, want %v", err, netErr)
+	}
+
+	if n := len(r.sent()); n != 3 {
+		t.Errorf("got %d attempts, want 3", n)
+	}
+
+	if !reflect.DeepEqual(r.reported, []interface{}{"a", "b"}) || r.errs[0] != netErr || r.errs[1] != netErr {
+		t.Errorf("ErrorHandler got %v %v, want both items with the send error", r.reported, r.errs)
+	}
+}
+
+func TestSendNoRetryOnClientError(t *testing.T) {
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		return nil, &StatusError{Op: "test", Status: http.StatusBadRequest, Body: "bad request"}
+	}}
+	b := newBatcher(t, r, Config{Retries: 3})
+
+	err := b.send(context.Background(), items("a", "b"))
+	if se, ok := err.(*StatusError); !ok || se.Status != http.StatusBadRequest {
+		t.Fatalf("send returned %v, want *StatusError 400", err)
+	}
+
+	if n := len(r.sent()); n != 1 {
+		t.Errorf("got %d attempts, want 1", n)
+	}
+
+	if !reflect.DeepEqual(r.reported, []interface{}{"a", "b"}) || r.errs[0] != err {
+		t.Errorf("ErrorHandler got %v %v, want both items with the status error", r.reported, r.errs)
+	}
+}
+
+func TestSendRetryAfter(t *testing.T) {
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		if n == 0 {
+			return nil, &StatusError{Status: http.StatusTooManyRequests, RetryAfter: 10 * time.Millisecond}
+		}
+		return nil, nil
+	}}
+	b := newBatcher(t, r, Config{Retries: 1, RetryBackoff: time.Hour, MaxRetryBackoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.send(ctx, items("a")); err != nil {
+		t.Fatalf("send returned %v, want Retry-After instead of RetryBackoff", err)
+	}
+}
+
+func TestSendCanceledDuringBackoff(t *testing.T) {
+	r := &recorder{respond: func(n int, batch []Item) ([]Item, error) {
+		return nil, &StatusError{Status: http.StatusServiceUnavailable}
+	}}
+	b := newBatcher(t, r, Config{Retries: 3, RetryBackoff: time.Hour, MaxRetryBackoff: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if err := b.send(ctx, items("a", "b")); err != context.Canceled {
+		t.Fatalf("send returned %v, want context.Canceled", err)
+	}
+
+	if n := len(r.sent()); n != 1 {
+		t.Errorf("got %d attempts, want 1", n)
+	}
+
+	if !reflect.DeepEqual(r.reported, []interface{}{"a", "b"}) || r.errs[0] != context.Canceled {
+		t.Errorf("ErrorHandler got %v %v, want both items with context.Canceled", r.reported, r.errs)
+	}
+}
@@ -0,0 +1,283 @@
+package journald
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/d-kolpakov/logger/v2"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const defaultSocket = "/run/systemd/journal/socket"
+
+// reservedPrefix добавляется к полям из Tags, Extra и Fields, имена которых совпали с reservedFields
+const reservedPrefix = "APP_"
+
+// reservedFields поля, которые драйвер пишет сам, и поля с особым смыслом для journald
+var reservedFields = map[string]bool{
+	"MESSAGE":            true,
+	"MESSAGE_ID":         true,
+	"PRIORITY":           true,
+	"SYSLOG_IDENTIFIER":  true,
+	"SYSLOG_FACILITY":    true,
+	"SYSLOG_PID":         true,
+	"SYSLOG_TIMESTAMP":   true,
+	"MESSAGE_TYPE":       true,
+	"LOGGER":             true,
+	"TRACE_ID":           true,
+	"SPAN_ID":            true,
+	"CODE_FILE":          true,
+	"CODE_LINE":          true,
+	"CODE_FUNC":          true,
+	"STACKTRACE":         true,
+	"ERRNO":              true,
+	"TID":                true,
+	"INVOCATION_ID":      true,
+	"USER_INVOCATION_ID": true,
+	"DOCUMENTATION":      true,
+	"UNIT":               true,
+	"USER_UNIT":          true,
+	"COREDUMP_UNIT":      true,
+	"COREDUMP_USER_UNIT": true,
+	"OBJECT_PID":         true,
+}
+
+// Priority важность сообщения в журнале, как у syslog
+type Priority int
+
+const (
+	PriEmerg Priority = iota
+	PriAlert
+	PriCrit
+	PriErr
+	PriWarning
+	PriNotice
+	PriInfo
+	PriDebug
+)
+
+var defaultPriorities = map[string]Priority{
+	"ALERT":   PriAlert,
+	"ERROR":   PriErr,
+	"LOG":     PriInfo,
+	"DEBUG":   PriDebug,
+	"TRACE":   PriDebug,
+	"UNKNOWN": PriNotice,
+}
+
+// JournaldDriver пишет в systemd-journald по нативному протоколу.
+// Tags, Extra и Fields становятся отдельными полями журнала в верхнем регистре,
+// место вызова из Stacktrace попадает в CODE_FILE, CODE_LINE и CODE_FUNC.
+// Сообщения, которые не помещаются в датаграмму, передаются через memfd
+type JournaldDriver struct {
+	// Socket путь к сокету журнала, по умолчанию /run/systemd/journal/socket
+	Socket string
+	// Identifier SYSLOG_IDENTIFIER, по умолчанию ServiceName сообщения
+	Identifier string
+	// Priorities соответствие MessageType приоритету, по умолчанию ALERT - PriAlert, ERROR - PriErr,
+	// LOG - PriInfo, DEBUG и TRACE - PriDebug
+	Priorities map[string]Priority
+	// Prefix добавляется к именам полей из Tags, Extra и Fields, например "APP_".
+	// Поля, имена которых совпали с полями драйвера или journald (MESSAGE, PRIORITY, CODE_LINE...),
+	// дополнительно получают префикс APP_
+	Prefix string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+func (j *JournaldDriver) Init() error {
+	if j.Socket == "" {
+		j.Socket = defaultSocket
+	}
+
+	if j.Priorities == nil || len(j.Priorities) <= 0 {
+		j.Priorities = defaultPriorities
+	}
+
+	// сокет открывается при первой записи, чтобы journald без сокета не мешал запуску
+	return nil
+}
+
+func (j *JournaldDriver) PutMsg(msg logger.Message) error {
+	payload := j.format(msg)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		if err := j.connect(); err != nil {
+			return err
+		}
+	}
+
+	_, err := j.conn.Write(payload)
+	if err == nil {
+		return nil
+	}
+
+	if isTooLarge(err) {
+		return sendMemfd(j.conn, payload)
+	}
+
+	// journald мог перезапуститься, сокет нужно открыть заново
+	j.conn.Close()
+	j.conn = nil
+
+	return err
+}
+
+// Close закрывает сокет
+func (j *JournaldDriver) Close(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		return nil
+	}
+
+	err := j.conn.Close()
+	j.conn = nil
+
+	return err
+}
+
+func (j *JournaldDriver) connect() error {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: j.Socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+
+	j.conn = conn
+
+	return nil
+}
+
+// isTooLarge сообщение не поместилось в датаграмму
+func isTooLarge(err error) bool {
+	errno, ok := underlyingErrno(err)
+
+	return ok && (errno == syscall.EMSGSIZE || errno == syscall.ENOBUFS)
+}
+
+func underlyingErrno(err error) (syscall.Errno, bool) {
+	for err != nil {
+		if errno, ok := err.(syscall.Errno); ok {
+			return errno, true
+		}
+
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return 0, false
+		}
+		err = u.Unwrap()
+	}
+
+	return 0, false
+}
+
+func (j *JournaldDriver) format(msg logger.Message) []byte {
+	priority, ok := j.Priorities[msg.MessageType]
+	if !ok {
+		priority = PriNotice
+	}
+
+	identifier := j.Identifier
+	if identifier == "" {
+		identifier = msg.ServiceName
+	}
+
+	var b bytes.Buffer
+	writeField(&b, "MESSAGE", logger.DataText(msg.Data))
+	writeField(&b, "PRIORITY", strconv.Itoa(int(priority)))
+	writeField(&b, "SYSLOG_IDENTIFIER", identifier)
+	writeField(&b, "MESSAGE_TYPE", msg.MessageType)
+	writeField(&b, "LOGGER", msg.Logger)
+	writeField(&b, "TRACE_ID", msg.TraceID)
+	writeField(&b, "SPAN_ID", msg.SpanID)
+
+	if st := msg.GetStacktrace(); st != nil && len(st.Frames) > 0 {
+		// кадры идут от внешнего к внутреннему, место вызова последнее
+		f := st.Frames[len(st.Frames)-1]
+		writeField(&b, "CODE_FILE", f.AbsPath)
+		writeField(&b, "CODE_LINE", strconv.Itoa(f.Lineno))
+		writeField(&b, "CODE_FUNC", f.Module+"."+f.Function)
+	}
+
+	if msg.Trace != "" {
+		writeField(&b, "STACKTRACE", msg.Trace)
+	}
+
+	values := make(map[string]string, len(msg.Tags)+len(msg.Extra)+len(msg.Fields))
+	for k, v := range msg.Extra {
+		values[k] = logger.DataText(v)
+	}
+	for k, v := range msg.Fields.Map() {
+		values[k] = logger.DataText(v)
+	}
+	for k, v := range msg.Tags {
+		values[k] = v
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name := fieldName(j.Prefix + k)
+		if reservedFields[name] {
+			name = reservedPrefix + name
+		}
+		writeField(&b, name, values[k])
+	}
+
+	return b.Bytes()
+}
+
+// writeField поле нативного протокола: KEY=value, многострочные значения с длиной в little endian
+func writeField(b *bytes.Buffer, key, value string) {
+	if key == "" || value == "" {
+		return
+	}
+
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+
+	b.WriteString(key)
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// fieldName имя поля журнала: A-Z, 0-9 и '_', не начинается с '_' и цифры, до 64 символов
+func fieldName(key string) string {
+	key = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+
+	key = strings.TrimLeft(key, "_0123456789")
+	if len(key) > 64 {
+		key = key[:64]
+	}
+
+	return key
+}
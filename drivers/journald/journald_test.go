@@ -0,0 +1,156 @@
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/d-kolpakov/logger/v2"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInitWithoutSocket(t *testing.T) {
+	j := &JournaldDriver{Socket: filepath.Join(t.TempDir(), "journal.sock")}
+	if err := j.Init(); err != nil {
+		t.Fatalf("Init must not dial the socket: %v", err)
+	}
+
+	if err := j.PutMsg(logger.Message{Data: "lost"}); err == nil {
+		t.Fatal("PutMsg without journald returned no error")
+	}
+
+	// journald появился после запуска
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: j.Socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := j.PutMsg(logger.Message{Data: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(buf[:n], []byte("MESSAGE=hello\n")) {
+		t.Errorf("unexpected payload %q", buf[:n])
+	}
+}
+
+func TestReservedFieldNames(t *testing.T) {
+	j := &JournaldDriver{}
+	if err := j.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := j.format(logger.Message{
+		MessageType: "LOG",
+		Data:        "real",
+		Tags:        map[string]string{"message": "tag", "priority": "high", "user": "bob"},
+		Extra:       map[string]interface{}{"code_line": 42},
+	})
+
+	fields := parseFields(t, payload)
+
+	want := map[string]string{
+		"MESSAGE":       "real",
+		"PRIORITY":      "6",
+		"APP_MESSAGE":   "tag",
+		"APP_PRIORITY":  "high",
+		"APP_CODE_LINE": "42",
+		"USER":          "bob",
+		"MESSAGE_TYPE":  "LOG",
+	}
+	for k, v := range want {
+		if got := fields[k]; len(got) != 1 || got[0] != v {
+			t.Errorf("%s = %v, want [%s]", k, got, v)
+		}
+	}
+}
+
+// parseFields разбирает payload нативного протокола, в том числе поля с длиной
+func parseFields(t *testing.T, payload []byte) map[string][]string {
+	t.Helper()
+
+	fields := make(map[string][]string)
+	for len(payload) > 0 {
+		i := bytes.IndexAny(payload, "=\n")
+		if i < 0 {
+			t.Fatalf("truncated payload %q", payload)
+		}
+
+		key := string(payload[:i])
+		if payload[i] == '=' {
+			payload = payload[i+1:]
+			end := bytes.IndexByte(payload, '\n')
+			fields[key] = append(fields[key], string(payload[:end]))
+			payload = payload[end+1:]
+			continue
+		}
+
+		payload = payload[i+1:]
+		size := binary.LittleEndian.Uint64(payload)
+		payload = payload[8:]
+		fields[key] = append(fields[key], string(payload[:size]))
+		payload = payload[size+1:]
+	}
+
+	return fields
+}
+
+func TestFormatCodeLocation(t *testing.T) {
+	j := &JournaldDriver{}
+	if err := j.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	trace := "example.com/app.handle(...)\n\t/src/app/handler.go:42\nmain.main(...)\n\t/src/app/main.go:10\n"
+	payload := j.format(logger.Message{
+		ServiceName: "svc",
+		MessageType: "ERROR",
+		Data:        "failed",
+		Trace:       trace,
+		Stacktrace: &logger.Stacktrace{Frames: []logger.Frame{
+			// от внешнего к внутреннему
+			{AbsPath: "/src/app/main.go", Lineno: 10, Module: "main", Function: "main"},
+			{AbsPath: "/src/app/handler.go", Lineno: 42, Module: "example.com/app", Function: "handle"},
+		}},
+	})
+
+	want := map[string]string{
+		"MESSAGE":           "failed",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "svc",
+		"CODE_FILE":         "/src/app/handler.go",
+		"CODE_LINE":         "42",
+		"CODE_FUNC":         "example.com/app.handle",
+		"STACKTRACE":        trace,
+	}
+
+	fields := parseFields(t, payload)
+	for k, v := range want {
+		if got := fields[k]; len(got) != 1 || got[0] != v {
+			t.Errorf("%s = %q, want [%q]", k, got, v)
+		}
+	}
+}
+
+func TestFormatWithoutStack(t *testing.T) {
+	j := &JournaldDriver{}
+	if err := j.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := parseFields(t, j.format(logger.Message{MessageType: "LOG", Data: "no stack"}))
+	for _, k := range []string{"CODE_FILE", "CODE_LINE", "CODE_FUNC", "STACKTRACE"} {
+		if got, ok := fields[k]; ok {
+			t.Errorf("%s = %q for a message without stack", k, got)
+		}
+	}
+}
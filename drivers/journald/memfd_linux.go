@@ -0,0 +1,44 @@
+package journald
+
+import (
+	"golang.org/x/sys/unix"
+	"net"
+)
+
+// sendMemfd передает сообщение через запечатанный memfd, так journald принимает записи больше датаграммы
+func sendMemfd(conn *net.UnixConn, payload []byte) error {
+	fd, err := unix.MemfdCreate("journald-logger", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	for n := 0; n < len(payload); {
+		m, err := unix.Write(fd, payload[n:])
+		if err != nil {
+			return err
+		}
+		n += m
+	}
+
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return err
+	}
+
+	// WriteMsgUnix не пишет в подключенный датаграммный сокет, поэтому sendmsg напрямую
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+	err = rc.Write(func(s uintptr) bool {
+		sendErr = unix.Sendmsg(int(s), nil, unix.UnixRights(fd), nil, 0)
+		return sendErr != unix.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+
+	return sendErr
+}
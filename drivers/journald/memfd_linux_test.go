@@ -0,0 +1,99 @@
+package journald
+
+import (
+	"bytes"
+	"context"
+	"github.com/d-kolpakov/logger/v2"
+	"golang.org/x/sys/unix"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sendBuffer размер буфера отправки сокета по умолчанию, больше него датаграмма не уходит
+func sendBuffer(t *testing.T, conn *net.UnixConn) int {
+	t.Helper()
+
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var size int
+	var serr error
+	if err := rc.Control(func(fd uintptr) {
+		size, serr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if serr != nil {
+		t.Fatal(serr)
+	}
+
+	return size
+}
+
+func TestLargeMessageSentThroughMemfd(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	j := &JournaldDriver{Socket: socket}
+	if err := j.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close(context.Background())
+
+	msg := logger.Message{MessageType: "LOG", Data: strings.Repeat("x", 2*sendBuffer(t, conn))}
+	if err := j.PutMsg(msg); err != nil {
+		t.Fatalf("PutMsg: %v", err)
+	}
+
+	// датаграмма без данных, в ней только дескриптор memfd
+	oob := make([]byte, unix.CmsgSpace(4))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(make([]byte, 16), oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("datagram carries %d bytes of data, want only the descriptor", n)
+	}
+
+	cmsgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(cmsgs) != 1 {
+		t.Fatalf("control messages %v: %v", cmsgs, err)
+	}
+
+	fds, err := unix.ParseUnixRights(&cmsgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("rights %v: %v", fds, err)
+	}
+	defer unix.Close(fds[0])
+
+	seals, err := unix.FcntlInt(uintptr(fds[0]), unix.F_GET_SEALS, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL; seals&want != want {
+		t.Errorf("memfd seals %#x, want %#x", seals, want)
+	}
+
+	want := j.format(msg)
+	got := make([]byte, len(want)+1)
+	m, err := unix.Pread(fds[0], got, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got[:m], want) {
+		t.Errorf("memfd holds %d bytes, want the %d byte payload", m, len(want))
+	}
+}
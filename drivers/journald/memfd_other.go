@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package journald
+
+import (
+	"errors"
+	"net"
+)
+
+func sendMemfd(conn *net.UnixConn, payload []byte) error {
+	return errors.New("journald: message is too large")
+}
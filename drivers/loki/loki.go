@@ -0,0 +1,272 @@
+package loki
+
+import (
+	"context"
+	"errors"
+	"github.com/d-kolpakov/logger/v2"
+	"github.com/d-kolpakov/logger/v2/drivers/internal/batch"
+	"github.com/d-kolpakov/logger/v2/drivers/stdout"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBatchSize       = 1000
+	defaultBatchBytes      = 1024 * 1024
+	defaultFlushInterval   = time.Second
+	defaultRetries         = 5
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultMaxRetryBackoff = 30 * time.Second
+	defaultTimeout         = 10 * time.Second
+
+	// maxTrackedStreams сколько потоков помнят время последней записи, дальше память сбрасывается
+	maxTrackedStreams = 10000
+)
+
+var errClosed = errors.New("loki driver is closed")
+
+// LokiDriver отправляет сообщения в Grafana Loki через push API пачками в gzip JSON.
+// Метками потока становятся service, level и теги из Labels, остальное попадает в строку
+// в том же JSON, что пишет stdout.STDOUTDriver. Записи внутри потока упорядочиваются по времени,
+// чтобы Loki не отклонял их как out of order. Protobuf со snappy не поддерживается
+type LokiDriver struct {
+	// URL адрес Loki, например http://localhost:3100, путь /loki/api/v1/push добавляется сам
+	URL    string
+	Client *http.Client
+	// TenantID заголовок X-Scope-OrgID для multi-tenant Loki
+	TenantID string
+	Username string
+	Password string
+	Headers  http.Header
+	// Labels теги, которые становятся метками потока. Держите список коротким, каждая комбинация значений это отдельный поток
+	Labels []string
+	// StaticLabels метки, одинаковые для всех сообщений, например env
+	StaticLabels  map[string]string
+	BatchSize     int
+	BatchBytes    int
+	FlushInterval time.Duration
+	DisableGzip   bool
+	// Retries повторы при ошибке сети, 429 и 5xx. По умолчанию 5, отрицательное значение отключает повторы
+	Retries         int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	Timeout         time.Duration
+	// ErrorHandler получает каждое недоставленное сообщение. По умолчанию сообщение уходит в Fallback драйвера,
+	// а ошибка в LoggerConfig.ErrorHandler логгера, драйвер без логгера пишет ошибку в stderr
+	ErrorHandler logger.ErrorHandler
+	LogRequest   map[string]struct{}
+	LogTrace     map[string]struct{}
+
+	formatter    stdout.STDOUTDriver
+	loggerErrors logger.ErrorHandler
+	labels       map[string]string
+	batcher      *batch.Batcher
+	// last время последней отправленной записи по потокам, меняется только в order
+	last map[string]time.Time
+}
+
+// entry данные элемента пачки
+type entry struct {
+	labels map[string]string
+	key    string
+	ts     time.Time
+	line   string
+}
+
+func (l *LokiDriver) Init() error {
+	if l.URL == "" {
+		return errors.New("loki: URL is required")
+	}
+	l.URL = strings.TrimRight(l.URL, "/")
+
+	if l.Client == nil {
+		l.Client = http.DefaultClient
+	}
+
+	if l.BatchSize <= 0 {
+		l.BatchSize = defaultBatchSize
+	}
+
+	if l.BatchBytes <= 0 {
+		l.BatchBytes = defaultBatchBytes
+	}
+
+	if l.FlushInterval <= 0 {
+		l.FlushInterval = defaultFlushInterval
+	}
+
+	if l.Retries < 0 {
+		l.Retries = 0
+	} else if l.Retries == 0 {
+		l.Retries = defaultRetries
+	}
+
+	if l.RetryBackoff <= 0 {
+		l.RetryBackoff = defaultRetryBackoff
+	}
+
+	if l.MaxRetryBackoff <= 0 {
+		l.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+
+	if l.Timeout <= 0 {
+		l.Timeout = defaultTimeout
+	}
+
+	if l.ErrorHandler == nil {
+		l.ErrorHandler = l.loggerErrors
+	}
+
+	if l.ErrorHandler == nil {
+		l.ErrorHandler = func(msg logger.Message, err error) {
+			log.Println(err)
+		}
+	}
+
+	l.labels = make(map[string]string, len(l.Labels))
+	for _, tag := range l.Labels {
+		l.labels[tag] = labelName(tag)
+	}
+
+	l.formatter = stdout.STDOUTDriver{LogRequest: l.LogRequest, LogTrace: l.LogTrace}
+	l.last = make(map[string]time.Time)
+	l.batcher = batch.New(batch.Config{
+		BatchSize:       l.BatchSize,
+		BatchBytes:      l.BatchBytes,
+		FlushInterval:   l.FlushInterval,
+		Retries:         l.Retries,
+		RetryBackoff:    l.RetryBackoff,
+		MaxRetryBackoff: l.MaxRetryBackoff,
+		ErrorHandler:    l.ErrorHandler,
+		Prepare:         l.order,
+		Send:            l.push,
+	})
+
+	return nil
+}
+
+// SetErrorHandler вызывается логгером, см. logger.ErrorReporter
+func (l *LokiDriver) SetErrorHandler(h logger.ErrorHandler) {
+	l.loggerErrors = h
+}
+
+func (l *LokiDriver) PutMsg(msg logger.Message) error {
+	labels := make(map[string]string, len(l.StaticLabels)+len(l.labels)+2)
+	for k, v := range l.StaticLabels {
+		labels[labelName(k)] = v
+	}
+	labels["service"] = msg.ServiceName
+	labels["level"] = msg.MessageType
+
+	// теги-метки убираются из строки, чтобы не дублировать их
+	line := msg
+	if len(msg.Tags) > 0 && len(l.labels) > 0 {
+		line.Tags = make(map[string]string, len(msg.Tags))
+		for k, v := range msg.Tags {
+			if name, ok := l.labels[k]; ok {
+				labels[name] = v
+				continue
+			}
+			line.Tags[k] = v
+		}
+	}
+
+	// Loki не принимает метки с пустым значением, убираются до ключа потока,
+	// чтобы сообщения с пустой меткой и без нее попадали в один поток
+	for k, v := range labels {
+		if v == "" {
+			delete(labels, k)
+		}
+	}
+
+	doc, err := l.formatter.Format(line)
+	if err != nil {
+		return err
+	}
+
+	ts := msg.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	e := &entry{labels: labels, key: labelsKey(labels), ts: ts, line: string(doc)}
+	if !l.batcher.Add(batch.Item{Msg: msg, Size: len(e.line), Data: e}) {
+		return errClosed
+	}
+
+	return nil
+}
+
+// Flush отправляет накопленную пачку
+func (l *LokiDriver) Flush(ctx context.Context) error {
+	return l.batcher.Flush(ctx)
+}
+
+// Close останавливает периодическую отправку и отправляет остаток
+func (l *LokiDriver) Close(ctx context.Context) error {
+	return l.batcher.Close(ctx)
+}
+
+// order сортирует записи каждого потока по времени и сдвигает записи не позже уже отправленных
+// на наносекунду вперед, Loki отклоняет записи потока старше последней.
+// Вызывается один раз для пачки, пачки не отправляются параллельно
+func (l *LokiDriver) order(items []batch.Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].Data.(*entry), items[j].Data.(*entry)
+		if a.key != b.key {
+			return a.key < b.key
+		}
+		return a.ts.Before(b.ts)
+	})
+
+	if len(l.last) > maxTrackedStreams {
+		l.last = make(map[string]time.Time)
+	}
+
+	for _, it := range items {
+		e := it.Data.(*entry)
+		last, ok := l.last[e.key]
+		if ok && !e.ts.After(last) {
+			e.ts = last.Add(time.Nanosecond)
+		}
+		l.last[e.key] = e.ts
+	}
+}
+
+// labelName имя метки Loki: [a-zA-Z_][a-zA-Z0-9_]*
+func labelName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// labelsKey ключ потока из отсортированных меток
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte(0)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,272 @@
+package loki
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/d-kolpakov/logger/v2"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pushServer запоминает запросы push API, статус ответа задает status по номеру запроса
+type pushServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []pushRequest
+	headers  []http.Header
+	times    []time.Time
+	status   func(n int, w http.ResponseWriter) int
+}
+
+func newPushServer(t *testing.T, status func(n int, w http.ResponseWriter) int) *pushServer {
+	s := &pushServer{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("bad gzip body: %v", err)
+				return
+			}
+			body = gz
+		}
+
+		var req pushRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			t.Errorf("bad push body: %v", err)
+		}
+
+		s.mu.Lock()
+		n := len(s.requests)
+		s.requests = append(s.requests, req)
+		s.headers = append(s.headers, r.Header.Clone())
+		s.times = append(s.times, time.Now())
+		s.mu.Unlock()
+
+		code := http.StatusNoContent
+		if s.status != nil {
+			code = s.status(n, w)
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *pushServer) pushes() []pushRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]pushRequest(nil), s.requests...)
+}
+
+func newDriver(t *testing.T, l *LokiDriver) *LokiDriver {
+	t.Helper()
+
+	if l.FlushInterval == 0 {
+		l.FlushInterval = time.Hour
+	}
+
+	if l.RetryBackoff == 0 {
+		l.RetryBackoff = time.Millisecond
+	}
+
+	if err := l.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close(context.Background()) })
+
+	return l
+}
+
+func flush(t *testing.T, l *LokiDriver) {
+	t.Helper()
+
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLabelsAndGzip(t *testing.T) {
+	s := newPushServer(t, nil)
+	l := newDriver(t, &LokiDriver{
+		URL:          s.URL,
+		TenantID:     "tenant",
+		Labels:       []string{"request.id"},
+		StaticLabels: map[string]string{"env": "test"},
+	})
+
+	ts := time.Unix(1700000000, 0)
+	msgs := []logger.Message{
+		{ServiceName: "svc", MessageType: "LOG", Timestamp: ts, Data: "a", Tags: map[string]string{"request.id": "r1", "user": "bob"}},
+		{ServiceName: "svc", MessageType: "LOG", Timestamp: ts.Add(time.Second), Data: "b", Tags: map[string]string{"request.id": "r2"}},
+		{ServiceName: "svc", MessageType: "ERROR", Timestamp: ts, Data: "c"},
+		{ServiceName: "svc", MessageType: "ERROR", Timestamp: ts.Add(time.Second), Data: "d", Tags: map[string]string{"request.id": ""}},
+	}
+	for _, m := range msgs {
+		if err := l.PutMsg(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flush(t, l)
+
+	pushes := s.pushes()
+	if len(pushes) != 1 {
+		t.Fatalf("got %d pushes, want 1", len(pushes))
+	}
+
+	if h := s.headers[0]; h.Get("Content-Encoding") != "gzip" || h.Get("X-Scope-OrgID") != "tenant" {
+		t.Errorf("unexpected headers %v", h)
+	}
+
+	streams := make(map[string]pushStream)
+	for _, st := range pushes[0].Streams {
+		streams[st.Stream["level"]+"/"+st.Stream["request_id"]] = st
+	}
+
+	want := map[string]map[string]string{
+		"LOG/r1": {"env": "test", "service": "svc", "level": "LOG", "request_id": "r1"},
+		"LOG/r2": {"env": "test", "service": "svc", "level": "LOG", "request_id": "r2"},
+		// пустые метки не отправляются
+		"ERROR/": {"env": "test", "service": "svc", "level": "ERROR"},
+	}
+	if len(streams) != len(want) {
+		t.Fatalf("got streams %v", pushes[0].Streams)
+	}
+
+	for key, labels := range want {
+		if !reflect.DeepEqual(streams[key].Stream, labels) {
+			t.Errorf("stream %s labels %v, want %v", key, streams[key].Stream, labels)
+		}
+	}
+
+	// пустая метка не отделяет поток от сообщений без нее
+	if n := len(streams["ERROR/"].Values); n != 2 {
+		t.Errorf("stream without request_id has %d values, want 2", n)
+	}
+
+	// тег-метка не дублируется в строке, остальные теги остаются
+	line := streams["LOG/r1"].Values[0][1]
+	if strings.Contains(line, "request.id") || !strings.Contains(line, `"user":"bob"`) {
+		t.Errorf("unexpected line %s", line)
+	}
+}
+
+func TestOrderAndNudge(t *testing.T) {
+	s := newPushServer(t, nil)
+	l := newDriver(t, &LokiDriver{URL: s.URL, DisableGzip: true})
+
+	ts := time.Unix(1700000000, 0)
+	put := func(data string, at time.Time) {
+		if err := l.PutMsg(logger.Message{ServiceName: "svc", MessageType: "LOG", Timestamp: at, Data: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put("late", ts.Add(time.Second))
+	put("early", ts)
+	flush(t, l)
+
+	// вторая пачка не новее уже отправленной записи потока
+	put("same", ts.Add(time.Second))
+	put("older", ts)
+	flush(t, l)
+
+	pushes := s.pushes()
+	if len(pushes) != 2 {
+		t.Fatalf("got %d pushes, want 2", len(pushes))
+	}
+
+	if h := s.headers[0]; h.Get("Content-Encoding") != "" {
+		t.Errorf("body gzipped with DisableGzip")
+	}
+
+	base := ts.Add(time.Second).UnixNano()
+	want := [][][2]string{
+		{{strconv.FormatInt(ts.UnixNano(), 10), "early"}, {strconv.FormatInt(base, 10), "late"}},
+		{{strconv.FormatInt(base+1, 10), "older"}, {strconv.FormatInt(base+2, 10), "same"}},
+	}
+
+	for i, p := range pushes {
+		if len(p.Streams) != 1 || len(p.Streams[0].Values) != 2 {
+			t.Fatalf("push %d: unexpected streams %v", i, p.Streams)
+		}
+
+		for j, v := range p.Streams[0].Values {
+			if v[0] != want[i][j][0] || !strings.Contains(v[1], `"`+want[i][j][1]+`"`) {
+				t.Errorf("push %d value %d = %s %s, want %s %s", i, j, v[0], v[1], want[i][j][0], want[i][j][1])
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	s := newPushServer(t, func(n int, w http.ResponseWriter) int {
+		if n == 0 {
+			w.Header().Set("Retry-After", "1")
+			return http.StatusTooManyRequests
+		}
+		return http.StatusNoContent
+	})
+	l := newDriver(t, &LokiDriver{URL: s.URL})
+
+	if err := l.PutMsg(logger.Message{MessageType: "LOG", Data: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	flush(t, l)
+
+	if len(s.times) != 2 {
+		t.Fatalf("got %d pushes, want 2", len(s.times))
+	}
+
+	// RetryBackoff 1ms, пауза берется из Retry-After
+	if wait := s.times[1].Sub(s.times[0]); wait < 900*time.Millisecond {
+		t.Errorf("retried after %s, want Retry-After 1s", wait)
+	}
+}
+
+func TestFailedPushReported(t *testing.T) {
+	s := newPushServer(t, func(n int, w http.ResponseWriter) int {
+		return http.StatusBadRequest
+	})
+
+	var mu sync.Mutex
+	var errs []error
+	l := newDriver(t, &LokiDriver{URL: s.URL, ErrorHandler: func(msg logger.Message, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}})
+
+	for _, d := range []string{"a", "b"} {
+		if err := l.PutMsg(logger.Message{MessageType: "LOG", Data: d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var se *StatusError
+	err := l.Flush(context.Background())
+	if se, _ = err.(*StatusError); se == nil || se.Status != http.StatusBadRequest || se.Op != "loki: push" {
+		t.Fatalf("Flush returned %v, want *StatusError 400", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 2 {
+		t.Errorf("ErrorHandler called %d times, want once per message", len(errs))
+	}
+}
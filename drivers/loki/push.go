@@ -0,0 +1,117 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/d-kolpakov/logger/v2/drivers/internal/batch"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatusError ответ push API с кодом не 2xx, RetryAfter из заголовка Retry-After
+type StatusError = batch.StatusError
+
+type pushRequest struct {
+	Streams []pushStream `json:"streams"`
+}
+
+type pushStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push один запрос с пачкой, уже упорядоченной order
+func (l *LokiDriver) push(ctx context.Context, items []batch.Item) ([]batch.Item, error) {
+	body, err := l.body(items)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, l.URL+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range l.Headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if !l.DisableGzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	if l.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", l.TenantID)
+	}
+
+	if l.Username != "" {
+		req.SetBasicAuth(l.Username, l.Password)
+	}
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil, nil
+	}
+
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	se := &StatusError{Op: "loki: push", Status: resp.StatusCode, Body: string(b)}
+
+	if seconds, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && seconds > 0 {
+		se.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return nil, se
+}
+
+// body пачка, разложенная по потокам; записи уже отсортированы order
+func (l *LokiDriver) body(items []batch.Item) ([]byte, error) {
+	var req pushRequest
+	streams := make(map[string]int)
+	for _, it := range items {
+		e := it.Data.(*entry)
+		i, ok := streams[e.key]
+		if !ok {
+			i = len(req.Streams)
+			streams[e.key] = i
+			req.Streams = append(req.Streams, pushStream{Stream: e.labels})
+		}
+
+		req.Streams[i].Values = append(req.Streams[i].Values, [2]string{strconv.FormatInt(e.ts.UnixNano(), 10), e.line})
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.DisableGzip {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
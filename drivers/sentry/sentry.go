@@ -1,6 +1,7 @@
 package sentry
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/d-kolpakov/logger/v2"
@@ -72,10 +73,22 @@ func (s *SentryDriver) PutMsg(msg logger.Message) error {
 	scope.SetTags(msg.Tags)
 	scope.SetExtras(msg.Extra)
 
+	for k, v := range msg.Fields.Map() {
+		scope.SetExtra(k, v)
+	}
+
 	if msg.Request != nil {
 		scope.SetRequest(msg.Request)
 	}
 
+	if msg.TraceID != "" {
+		scope.SetContext("trace", map[string]interface{}{
+			"trace_id":    msg.TraceID,
+			"span_id":     msg.SpanID,
+			"trace_flags": msg.TraceFlags,
+		})
+	}
+
 	if msg.User != nil {
 		scope.SetUser(sentry.User{
 			Email:     msg.User.Email,
@@ -93,11 +106,29 @@ func (s *SentryDriver) PutMsg(msg logger.Message) error {
 		event = eventFromMessage(msg, level)
 	}
 
+	if !msg.Timestamp.IsZero() {
+		event.Timestamp = msg.Timestamp
+	}
+
 	s.Client.CaptureEvent(event, nil, scope)
 
 	return nil
 }
 
+// Flush ждет отправки накопленных событий, не дольше дедлайна ctx или FlushTimeout
+func (s *SentryDriver) Flush(ctx context.Context) error {
+	timeout := s.FlushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	if !s.Client.Flush(timeout) {
+		return errors.New("sentry: flush timeout")
+	}
+
+	return nil
+}
+
 func eventFromException(msg logger.Message, level sentry.Level) *sentry.Event {
 	var err, capturedError error
 
@@ -138,7 +169,7 @@ func eventFromException(msg logger.Message, level sentry.Level) *sentry.Event {
 	// We only add to the most recent error to avoid duplication and because the
 	// current stack is most likely unrelated to errors deeper in the chain.
 	if event.Exception[0].Stacktrace == nil {
-		event.Exception[0].Stacktrace = convertLoggerTraceToSentryTrace(msg.Stacktrace)
+		event.Exception[0].Stacktrace = convertLoggerTraceToSentryTrace(msg.GetStacktrace())
 	}
 
 	// event.Exception should be sorted such that the most recent error is last.
@@ -202,7 +233,7 @@ func eventFromMessage(msg logger.Message, level sentry.Level) *sentry.Event {
 	event.Message = message
 
 	event.Threads = []sentry.Thread{{
-		Stacktrace: convertLoggerTraceToSentryTrace(msg.Stacktrace),
+		Stacktrace: convertLoggerTraceToSentryTrace(msg.GetStacktrace()),
 		Crashed:    false,
 		Current:    true,
 	}}
@@ -1,5 +1,7 @@
 package stdout
 
+//go:generate easyjson stdout.go
+
 import (
 	"bytes"
 	"fmt"
@@ -31,6 +33,17 @@ type stdoutMsg struct {
 }
 
 func (s *STDOUTDriver) PutMsg(msg logger.Message) error {
+	logMsg, err := s.Format(msg)
+	if err != nil {
+		return err
+	}
+
+	return s.baseLog.Output(2, string(logMsg))
+}
+
+// Format сообщение в JSON, который пишет PutMsg, без перевода строки.
+// Можно использовать без Init, например в других драйверах
+func (s *STDOUTDriver) Format(msg logger.Message) ([]byte, error) {
 	fmsg := stdoutMsg{Message: msg}
 
 	needLogRequest := true
@@ -59,13 +72,7 @@ func (s *STDOUTDriver) PutMsg(msg logger.Message) error {
 		fmsg.Request = s.formRequest(msg.Request)
 	}
 
-	logMsg, err := fmsg.MarshalJSON()
-	if err != nil {
-		s.baseLog.Fatalln(err)
-	}
-	s.baseLog.Println(string(logMsg))
-
-	return nil
+	return fmsg.MarshalJSON()
 }
 
 func (s *STDOUTDriver) formRequest(r *http.Request) string {
@@ -77,7 +84,7 @@ func (s *STDOUTDriver) formRequest(r *http.Request) string {
 
 	bodyBytes, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		s.baseLog.Println(err.Error())
+		s.printErr(err)
 		return res
 	}
 
@@ -86,7 +93,7 @@ func (s *STDOUTDriver) formRequest(r *http.Request) string {
 	byteDump, err := httputil.DumpRequest(r, false)
 
 	if err != nil {
-		s.baseLog.Println(err.Error())
+		s.printErr(err)
 		return res
 	}
 
@@ -95,6 +102,15 @@ func (s *STDOUTDriver) formRequest(r *http.Request) string {
 	return res
 }
 
+func (s *STDOUTDriver) printErr(err error) {
+	if s.baseLog == nil {
+		log.Println(err.Error())
+		return
+	}
+
+	s.baseLog.Println(err.Error())
+}
+
 type dataMsg struct {
 	DataMsg interface{} `json:"data_msg"`
 }
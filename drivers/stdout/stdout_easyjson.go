@@ -4,7 +4,7 @@ package stdout
 
 import (
 	json "encoding/json"
-	logger "github.com/d-kolpakov/logger/v2"
+	_v2 "github.com/d-kolpakov/logger/v2"
 	easyjson "github.com/mailru/easyjson"
 	jlexer "github.com/mailru/easyjson/jlexer"
 	jwriter "github.com/mailru/easyjson/jwriter"
@@ -18,7 +18,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson46f1aa61DecodeGithubComDKolpakovLoggerDriversStdout(in *jlexer.Lexer, out *stdoutMsg) {
+func easyjson46f1aa61DecodeGithubComDKolpakovLoggerV2DriversStdout(in *jlexer.Lexer, out *stdoutMsg) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -43,8 +43,18 @@ func easyjson46f1aa61DecodeGithubComDKolpakovLoggerDriversStdout(in *jlexer.Lexe
 			out.Request = string(in.String())
 		case "service_name":
 			out.ServiceName = string(in.String())
+		case "logger":
+			out.Logger = string(in.String())
 		case "date":
 			out.Time = string(in.String())
+		case "seq":
+			out.Seq = uint64(in.Uint64())
+		case "trace_id":
+			out.TraceID = string(in.String())
+		case "span_id":
+			out.SpanID = string(in.String())
+		case "trace_flags":
+			out.TraceFlags = string(in.String())
 		case "message_type":
 			out.MessageType = string(in.String())
 		case "trace":
@@ -57,9 +67,9 @@ func easyjson46f1aa61DecodeGithubComDKolpakovLoggerDriversStdout(in *jlexer.Lexe
 				out.Stacktrace = nil
 			} else {
 				if out.Stacktrace == nil {
-					out.Stacktrace = new(logger.Stacktrace)
+					out.Stacktrace = new(_v2.Stacktrace)
 				}
-				easyjson46f1aa61DecodeGithubComDKolpakovLogger(in, out.Stacktrace)
+				easyjson46f1aa61DecodeGithubComDKolpakovLoggerV2(in, out.Stacktrace)
 			}
 		case "data":
 			if m, ok := out.Data.(easyjson.Unmarshaler); ok {
@@ -115,13 +125,15 @@ func easyjson46f1aa61DecodeGithubComDKolpakovLoggerDriversStdout(in *jlexer.Lexe
 				}
 				in.Delim('}')
 			}
+		case "fields":
+			(out.Fields).UnmarshalEasyJSON(in)
 		case "user":
 			if in.IsNull() {
 				in.Skip()
 				out.User = nil
 			} else {
 				if out.User == nil {
-					out.User = new(logger.UserForLog)
+					out.User = new(_v2.UserForLog)
 				}
 				(*out.User).UnmarshalEasyJSON(in)
 			}
@@ -135,7 +147,7 @@ func easyjson46f1aa61DecodeGithubComDKolpakovLoggerDriversStdout(in *jlexer.Lexe
 		in.Consumed()
 	}
 }
-func easyjson46f1aa61EncodeGithubComDKolpakovLoggerDriversStdout(out *jwriter.Writer, in stdoutMsg) {
+func easyjson46f1aa61EncodeGithubComDKolpakovLoggerV2DriversStdout(out *jwriter.Writer, in stdoutMsg) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -165,11 +177,36 @@ func easyjson46f1aa61EncodeGithubComDKolpakovLoggerDriversStdout(out *jwriter.Wr
 		}
 		out.String(string(in.ServiceName))
 	}
+	if in.Logger != "" {
+		const prefix string = ",\"logger\":"
+		out.RawString(prefix)
+		out.String(string(in.Logger))
+	}
 	{
 		const prefix string = ",\"date\":"
 		out.RawString(prefix)
 		out.String(string(in.Time))
 	}
+	if in.Seq != 0 {
+		const prefix string = ",\"seq\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Seq))
+	}
+	if in.TraceID != "" {
+		const prefix string = ",\"trace_id\":"
+		out.RawString(prefix)
+		out.String(string(in.TraceID))
+	}
+	if in.SpanID != "" {
+		const prefix string = ",\"span_id\":"
+		out.RawString(prefix)
+		out.String(string(in.SpanID))
+	}
+	if in.TraceFlags != "" {
+		const prefix string = ",\"trace_flags\":"
+		out.RawString(prefix)
+		out.String(string(in.TraceFlags))
+	}
 	{
 		const prefix string = ",\"message_type\":"
 		out.RawString(prefix)
@@ -188,7 +225,7 @@ func easyjson46f1aa61EncodeGithubComDKolpakovLoggerDriversStdout(out *jwriter.Wr
 	if in.Stacktrace != nil {
 		const prefix string = ",\"stacktrace\":"
 		out.RawString(prefix)
-		easyjson46f1aa61EncodeGithubComDKolpakovLogger(out, *in.Stacktrace)
+		easyjson46f1aa61EncodeGithubComDKolpakovLoggerV2(out, *in.Stacktrace)
 	}
 	{
 		const prefix string = ",\"data\":"
@@ -245,6 +282,11 @@ func easyjson46f1aa61EncodeGithubComDKolpakovLoggerDriversStdout(out *jwriter.Wr
 			out.RawByte('}')
 		}
 	}
+	if len(in.Fields) != 0 {
+		const prefix string = ",\"fields\":"
+		out.RawString(prefix)
+		(in.Fields).MarshalEasyJSON(out)
+	}
 	if in.User != nil {
 		const prefix string = ",\"user\":"
 		out.RawString(prefix)
@@ -256,27 +298,27 @@ func easyjson46f1aa61EncodeGithubComDKolpakovLoggerDriversStdout(out *jwriter.Wr
 // MarshalJSON supports json.Marshaler interface
 func (v stdoutMsg) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson46f1aa61EncodeGithubComDKolpakovLoggerDriversStdout(&w, v)
+	easyjson46f1aa61EncodeGithubComDKolpakovLoggerV2DriversStdout(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v stdoutMsg) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson46f1aa61EncodeGithubComDKolpakovLoggerDriversStdout(w, v)
+	easyjson46f1aa61EncodeGithubComDKolpakovLoggerV2DriversStdout(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *stdoutMsg) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson46f1aa61DecodeGithubComDKolpakovLoggerDriversStdout(&r, v)
+	easyjson46f1aa61DecodeGithubComDKolpakovLoggerV2DriversStdout(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *stdoutMsg) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson46f1aa61DecodeGithubComDKolpakovLoggerDriversStdout(l, v)
+	easyjson46f1aa61DecodeGithubComDKolpakovLoggerV2DriversStdout(l, v)
 }
-func easyjson46f1aa61DecodeGithubComDKolpakovLogger(in *jlexer.Lexer, out *logger.Stacktrace) {
+func easyjson46f1aa61DecodeGithubComDKolpakovLoggerV2(in *jlexer.Lexer, out *_v2.Stacktrace) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -303,16 +345,16 @@ func easyjson46f1aa61DecodeGithubComDKolpakovLogger(in *jlexer.Lexer, out *logge
 				in.Delim('[')
 				if out.Frames == nil {
 					if !in.IsDelim(']') {
-						out.Frames = make([]logger.Frame, 0, 0)
+						out.Frames = make([]_v2.Frame, 0, 0)
 					} else {
-						out.Frames = []logger.Frame{}
+						out.Frames = []_v2.Frame{}
 					}
 				} else {
 					out.Frames = (out.Frames)[:0]
 				}
 				for !in.IsDelim(']') {
-					var v5 logger.Frame
-					easyjson46f1aa61DecodeGithubComDKolpakovLogger1(in, &v5)
+					var v5 _v2.Frame
+					easyjson46f1aa61DecodeGithubComDKolpakovLoggerV21(in, &v5)
 					out.Frames = append(out.Frames, v5)
 					in.WantComma()
 				}
@@ -351,7 +393,7 @@ func easyjson46f1aa61DecodeGithubComDKolpakovLogger(in *jlexer.Lexer, out *logge
 		in.Consumed()
 	}
 }
-func easyjson46f1aa61EncodeGithubComDKolpakovLogger(out *jwriter.Writer, in logger.Stacktrace) {
+func easyjson46f1aa61EncodeGithubComDKolpakovLoggerV2(out *jwriter.Writer, in _v2.Stacktrace) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -365,7 +407,7 @@ func easyjson46f1aa61EncodeGithubComDKolpakovLogger(out *jwriter.Writer, in logg
 				if v7 > 0 {
 					out.RawByte(',')
 				}
-				easyjson46f1aa61EncodeGithubComDKolpakovLogger1(out, v8)
+				easyjson46f1aa61EncodeGithubComDKolpakovLoggerV21(out, v8)
 			}
 			out.RawByte(']')
 		}
@@ -391,7 +433,7 @@ func easyjson46f1aa61EncodeGithubComDKolpakovLogger(out *jwriter.Writer, in logg
 	}
 	out.RawByte('}')
 }
-func easyjson46f1aa61DecodeGithubComDKolpakovLogger1(in *jlexer.Lexer, out *logger.Frame) {
+func easyjson46f1aa61DecodeGithubComDKolpakovLoggerV21(in *jlexer.Lexer, out *_v2.Frame) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -512,7 +554,7 @@ func easyjson46f1aa61DecodeGithubComDKolpakovLogger1(in *jlexer.Lexer, out *logg
 		in.Consumed()
 	}
 }
-func easyjson46f1aa61EncodeGithubComDKolpakovLogger1(out *jwriter.Writer, in logger.Frame) {
+func easyjson46f1aa61EncodeGithubComDKolpakovLoggerV21(out *jwriter.Writer, in _v2.Frame) {
 	out.RawByte('{')
 	first := true
 	_ = first
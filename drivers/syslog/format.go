@@ -0,0 +1,132 @@
+package syslog
+
+import (
+	"fmt"
+	"github.com/d-kolpakov/logger/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const rfc5424Time = "2006-01-02T15:04:05.000000Z07:00"
+
+// format сообщение по RFC 5424 без фрейминга:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *SyslogDriver) format(msg logger.Message, severity Severity) []byte {
+	ts := msg.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	appName := s.AppName
+	if appName == "" {
+		appName = msg.ServiceName
+	}
+
+	var b strings.Builder
+	b.WriteString("<")
+	b.WriteString(strconv.Itoa(int(s.Facility)*8 + int(severity)))
+	b.WriteString(">1 ")
+	b.WriteString(ts.Format(rfc5424Time))
+	b.WriteByte(' ')
+	b.WriteString(headerField(s.Hostname, 255))
+	b.WriteByte(' ')
+	b.WriteString(headerField(appName, 48))
+	b.WriteByte(' ')
+	b.WriteString(headerField(s.pid, 128))
+	b.WriteByte(' ')
+	b.WriteString(headerField(msg.MessageType, 32))
+	b.WriteByte(' ')
+
+	sd := len(msg.Tags) > 0 || len(msg.Fields) > 0
+	if len(msg.Tags) > 0 {
+		writeElement(&b, s.SDID, msg.Tags)
+	}
+
+	if len(msg.Fields) > 0 {
+		fields := make(map[string]string, len(msg.Fields))
+		for k, v := range msg.Fields.Map() {
+			fields[k] = fmt.Sprint(v)
+		}
+		writeElement(&b, defaultFieldsSDID, fields)
+	}
+
+	if !sd {
+		b.WriteByte('-')
+	}
+
+	if text := logger.DataText(msg.Data); text != "" {
+		b.WriteByte(' ')
+		b.WriteString(text)
+	}
+
+	return []byte(b.String())
+}
+
+// writeElement элемент structured data [id key="value" ...], ключи по алфавиту
+func writeElement(b *strings.Builder, id string, params map[string]string) {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteByte('[')
+	b.WriteString(sdName(id))
+	for _, k := range keys {
+		name := sdName(k)
+		if name == "" {
+			continue
+		}
+
+		b.WriteByte(' ')
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(sdValue(params[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte(']')
+}
+
+// headerField поле заголовка: печатные ASCII символы без пробелов, пустое поле "-"
+func headerField(v string, max int) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, v)
+
+	if len(v) > max {
+		v = v[:max]
+	}
+
+	if v == "" {
+		return "-"
+	}
+
+	return v
+}
+
+// sdName имя параметра или элемента: до 32 печатных ASCII символов кроме '=', ' ', ']', '"'
+func sdName(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, v)
+
+	if len(v) > 32 {
+		v = v[:32]
+	}
+
+	return v
+}
+
+var sdValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func sdValue(v string) string {
+	return sdValueEscaper.Replace(v)
+}
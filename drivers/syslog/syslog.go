@@ -0,0 +1,296 @@
+package syslog
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/d-kolpakov/logger/v2"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Facility источник сообщения по RFC 5424
+type Facility int
+
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	Lpr
+	News
+	Uucp
+	Cron
+	Authpriv
+	Ftp
+	Ntp
+	Audit
+	Console
+	Cron2
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// Severity важность сообщения по RFC 5424
+type Severity int
+
+const (
+	Emergency Severity = iota
+	Alert
+	Critical
+	Error
+	Warning
+	Notice
+	Informational
+	Debug
+)
+
+// Framing разделение сообщений в потоковом соединении
+type Framing int
+
+const (
+	// FramingAuto octet-counting для tcp и tls, перевод строки для unix
+	FramingAuto Framing = iota
+	// OctetCounting длина сообщения перед ним, RFC 6587 3.4.1
+	OctetCounting
+	// NonTransparent перевод строки после сообщения, RFC 6587 3.4.2
+	NonTransparent
+)
+
+const (
+	defaultSDID         = "tags@32473"
+	defaultFieldsSDID   = "fields@32473"
+	defaultDialTimeout  = 5 * time.Second
+	defaultWriteTimeout = 5 * time.Second
+)
+
+var defaultSeverities = map[string]Severity{
+	"ALERT":   Alert,
+	"ERROR":   Error,
+	"LOG":     Informational,
+	"DEBUG":   Debug,
+	"TRACE":   Debug,
+	"UNKNOWN": Notice,
+}
+
+// локальные сокеты syslog, которые пробуются, если Network пустой
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogDriver отправляет сообщения в syslog по RFC 5424.
+// Tags попадают в structured data, Fields в отдельный элемент structured data.
+// При ошибке записи соединение переустанавливается
+type SyslogDriver struct {
+	// Network "udp", "tcp", "tls", "unix", "unixgram", пустой пишет в локальный /dev/log
+	Network string
+	// Addr адрес сервера, для unix путь к сокету
+	Addr      string
+	TLSConfig *tls.Config
+	// Facility по умолчанию User, Kern сообщениям приложений не назначается
+	Facility Facility
+	// Severities соответствие MessageType важности, по умолчанию ALERT - Alert, ERROR - Error,
+	// LOG - Informational, DEBUG и TRACE - Debug
+	Severities map[string]Severity
+	// Hostname по умолчанию os.Hostname
+	Hostname string
+	// AppName по умолчанию ServiceName сообщения
+	AppName string
+	// SDID идентификатор элемента structured data с тегами, по умолчанию tags@32473
+	SDID string
+	// Framing для потоковых соединений, по умолчанию octet-counting для tcp и tls
+	Framing      Framing
+	DialTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	stream  bool
+	framing Framing
+	pid     string
+	// broken сервер закрыл потоковое соединение, без этого первая запись после закрытия теряется
+	broken bool
+}
+
+func (s *SyslogDriver) Init() error {
+	switch s.Network {
+	case "", "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "tls", "unix", "unixgram":
+	default:
+		return fmt.Errorf("syslog: unknown network %q", s.Network)
+	}
+
+	if s.Network != "" && s.Addr == "" {
+		return errors.New("syslog: Addr is required")
+	}
+
+	if s.Severities == nil || len(s.Severities) <= 0 {
+		s.Severities = defaultSeverities
+	}
+
+	if s.Facility == Kern {
+		s.Facility = User
+	}
+
+	if s.Hostname == "" {
+		s.Hostname, _ = os.Hostname()
+	}
+
+	if s.SDID == "" {
+		s.SDID = defaultSDID
+	}
+
+	if s.DialTimeout <= 0 {
+		s.DialTimeout = defaultDialTimeout
+	}
+
+	if s.WriteTimeout <= 0 {
+		s.WriteTimeout = defaultWriteTimeout
+	}
+
+	s.pid = strconv.Itoa(os.Getpid())
+
+	// соединение устанавливается при первой записи, чтобы недоступный сервер не мешал запуску
+	return nil
+}
+
+func (s *SyslogDriver) PutMsg(msg logger.Message) error {
+	severity, ok := s.Severities[msg.MessageType]
+	if !ok {
+		severity = Notice
+	}
+
+	line := s.format(msg, severity)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.write(line)
+	if err == nil {
+		return nil
+	}
+
+	// соединение могло быть разорвано сервером, пробуем один раз переподключиться
+	s.closeConn()
+
+	return s.write(line)
+}
+
+// Close закрывает соединение
+func (s *SyslogDriver) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closeConn()
+}
+
+func (s *SyslogDriver) write(line []byte) error {
+	if s.broken {
+		s.closeConn()
+	}
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	if s.stream {
+		switch s.framing {
+		case OctetCounting:
+			line = append([]byte(strconv.Itoa(len(line))+" "), line...)
+		case NonTransparent:
+			line = append(line, '\n')
+		}
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+	_, err := s.conn.Write(line)
+
+	return err
+}
+
+func (s *SyslogDriver) connect() error {
+	var conn net.Conn
+	var err error
+
+	switch s.Network {
+	case "":
+		conn, err = s.dialLocal()
+	case "tls":
+		dialer := &net.Dialer{Timeout: s.DialTimeout}
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.Addr, s.TLSConfig)
+	default:
+		conn, err = net.DialTimeout(s.Network, s.Addr, s.DialTimeout)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	s.broken = false
+	network := conn.RemoteAddr().Network()
+	s.stream = network != "udp" && network != "unixgram"
+	if s.stream {
+		go s.watch(conn)
+	}
+	s.framing = s.Framing
+	if s.framing == FramingAuto {
+		s.framing = OctetCounting
+		if _, ok := conn.(*net.UnixConn); ok {
+			s.framing = NonTransparent
+		}
+	}
+
+	return nil
+}
+
+// watch ждет закрытия соединения сервером, сервер syslog ничего не присылает в ответ
+func (s *SyslogDriver) watch(conn net.Conn) {
+	buf := make([]byte, 1)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	if s.conn == conn {
+		s.broken = true
+	}
+	s.mu.Unlock()
+}
+
+func (s *SyslogDriver) dialLocal() (net.Conn, error) {
+	for _, path := range localSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, path, s.DialTimeout)
+			if err == nil {
+				return conn, nil
+			}
+		}
+	}
+
+	return nil, errors.New("syslog: local syslog socket not found")
+}
+
+func (s *SyslogDriver) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
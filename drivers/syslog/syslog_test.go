@@ -0,0 +1,212 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"github.com/d-kolpakov/logger/v2"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readOctetCounted читает сообщения в формате RFC 6587 "LEN SP MSG"
+func readOctetCounted(r *bufio.Reader) (string, error) {
+	n, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+
+	size, err := strconv.Atoi(strings.TrimSuffix(n, " "))
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// tcpServer принимает соединения и отдает прочитанные сообщения в канал
+func tcpServer(t *testing.T, handle func(conn net.Conn, msgs chan<- string)) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	msgs := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn, msgs)
+		}
+	}()
+
+	return ln.Addr().String(), msgs
+}
+
+func receive(t *testing.T, msgs <-chan string) string {
+	t.Helper()
+
+	select {
+	case m := <-msgs:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func newDriver(t *testing.T, s *SyslogDriver) *SyslogDriver {
+	t.Helper()
+
+	if s.Hostname == "" {
+		s.Hostname = "host"
+	}
+
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close(context.Background()) })
+
+	return s
+}
+
+func TestTCPOctetCounting(t *testing.T) {
+	addr, msgs := tcpServer(t, func(conn net.Conn, msgs chan<- string) {
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			m, err := readOctetCounted(r)
+			if err != nil {
+				return
+			}
+			msgs <- m
+		}
+	})
+
+	s := newDriver(t, &SyslogDriver{Network: "tcp", Addr: addr, AppName: "app"})
+
+	ts := time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	err := s.PutMsg(logger.Message{
+		MessageType: "ERROR",
+		Timestamp:   ts,
+		Data:        "first line\nsecond line",
+		Tags:        map[string]string{"requestId": `r"1]`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.PutMsg(logger.Message{MessageType: "LOG", Timestamp: ts, Data: "plain"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<11>1 2024-03-01T12:30:45.000000Z host app ` + s.pid + ` ERROR [tags@32473 requestId="r\"1\]"] first line` + "\nsecond line"
+	if got := receive(t, msgs); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	want = `<14>1 2024-03-01T12:30:45.000000Z host app ` + s.pid + ` LOG - plain`
+	if got := receive(t, msgs); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s := newDriver(t, &SyslogDriver{Network: "udp", Addr: pc.LocalAddr().String(), Facility: Local3})
+
+	for _, data := range []string{"one", "two"} {
+		if err := s.PutMsg(logger.Message{MessageType: "DEBUG", ServiceName: "svc", Data: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := make([]byte, 2048)
+	for _, data := range []string{"one", "two"} {
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// датаграмма без фрейминга, Local3 * 8 + Debug = 159
+		got := string(buf[:n])
+		if !strings.HasPrefix(got, "<159>1 ") || !strings.HasSuffix(got, " svc "+s.pid+" DEBUG - "+data) {
+			t.Errorf("unexpected datagram %q", got)
+		}
+	}
+}
+
+func TestReconnectAfterServerClose(t *testing.T) {
+	conns := make(chan struct{}, 10)
+	addr, msgs := tcpServer(t, func(conn net.Conn, msgs chan<- string) {
+		conns <- struct{}{}
+
+		m, err := readOctetCounted(bufio.NewReader(conn))
+		if err == nil {
+			msgs <- m
+		}
+
+		// сервер закрывает соединение после каждого сообщения
+		conn.Close()
+	})
+
+	s := newDriver(t, &SyslogDriver{Network: "tcp", Addr: addr})
+
+	for i := 0; i < 3; i++ {
+		data := "msg-" + strconv.Itoa(i)
+		if err := s.PutMsg(logger.Message{MessageType: "LOG", Data: data}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := receive(t, msgs); !strings.HasSuffix(got, " "+data) {
+			t.Fatalf("got %q, want message %s", got, data)
+		}
+
+		waitBroken(t, s)
+	}
+
+	if n := len(conns); n != 3 {
+		t.Errorf("server accepted %d connections, want 3", n)
+	}
+}
+
+// waitBroken ждет, пока драйвер заметит закрытие соединения сервером
+func waitBroken(t *testing.T, s *SyslogDriver) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		broken := s.broken
+		s.mu.Unlock()
+
+		if broken {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("driver did not notice the closed connection")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
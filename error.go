@@ -14,3 +14,73 @@ func (e *ErrorMsg) Error() string {
 func (e *ErrorMsg) GetOriginError() error {
 	return e.err
 }
+
+// DriverError ошибка доставки сообщения драйвером после всех попыток
+type DriverError struct {
+	Driver   LogDriver
+	Attempts int
+	err      error
+}
+
+func (e *DriverError) Error() string {
+	return fmt.Sprintf("log driver %T failed after %d attempt(s): %s", e.Driver, e.Attempts, e.err.Error())
+}
+
+func (e *DriverError) Unwrap() error {
+	return e.err
+}
+
+func (e *DriverError) GetOriginError() error {
+	return e.err
+}
+
+// ProcessorError паника процессора из LoggerConfig.Processors, сообщение ушло дальше без его изменений.
+// Оборачивает *PanicError
+type ProcessorError struct {
+	Processor Processor
+	err       error
+}
+
+func (e *ProcessorError) Error() string {
+	return fmt.Sprintf("log processor %T: %s", e.Processor, e.err.Error())
+}
+
+func (e *ProcessorError) Unwrap() error {
+	return e.err
+}
+
+func (e *ProcessorError) GetOriginError() error {
+	return e.err
+}
+
+// FlushError контекст Flush или Shutdown истек раньше, чем были доставлены все сообщения
+type FlushError struct {
+	Lost int64
+	err  error
+}
+
+func (e *FlushError) Error() string {
+	return fmt.Sprintf("error: %s, lost messages: %d", e.err.Error(), e.Lost)
+}
+
+func (e *FlushError) Unwrap() error {
+	return e.err
+}
+
+func (e *FlushError) GetOriginError() error {
+	return e.err
+}
+
+// PanicError паника, перехваченная Recover, Go или переданная в ReportPanic
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -55,15 +55,23 @@ func main() {
 
 	l.NewLogEvent().Debug(context.Background(), fmt.Sprintf(`start %s service`, "test"))
 
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, "requestId", "4fd7d2c0-df29-4ad8-b6e3-1d0c2805a5bf")
-	ctx = context.WithValue(ctx, "source", "example")
-	ctx = context.WithValue(ctx, "accountId", "11728654")
+	ctx := logger.ContextWithTags(context.Background(), map[string]string{
+		"requestId": "4fd7d2c0-df29-4ad8-b6e3-1d0c2805a5bf",
+		"source":    "example",
+		"accountId": "11728654",
+	})
 
 	l.NewLogEvent().WithTag("is_done", "yeap").WithExtra("ddd", 54).Alert(ctx, errors.New("very new alert"))
 	l.NewLogEvent().WithTag("is_new", "true").WithExtra("xxx", 5412).Alert(ctx, errors.New("хочу увидеть стек-трейс"))
 	l.NewLogEvent().WithTag("is_new", "true").WithExtra("xxx", 5412).Debug(ctx, errors.New("хочу увидеть стек-трейс дебага"))
+	l.NewLogEvent().WithFields(logger.String("order_id", "42"), logger.Duration("elapsed", 150*time.Millisecond)).Log(ctx, "order processed")
 	l.NewLogEvent().WithTag("is_new", "true").WithExtra("xxx", 5412).Trace(ctx, errors.New("не выведет, так как Trace выше Debug"))
 
-	time.Sleep(time.Second * 100)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = l.Shutdown(shutdownCtx)
+	if err != nil {
+		fmt.Println(err)
+	}
 }
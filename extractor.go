@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// ContextValues то, что ContextExtractor добавляет в сообщение
+type ContextValues struct {
+	Tags  map[string]string
+	Extra map[string]interface{}
+	User  *UserForLog
+}
+
+func (v *ContextValues) SetTag(key, value string) {
+	if v.Tags == nil {
+		v.Tags = make(map[string]string)
+	}
+
+	v.Tags[key] = value
+}
+
+func (v *ContextValues) SetExtra(key string, value interface{}) {
+	if v.Extra == nil {
+		v.Extra = make(map[string]interface{})
+	}
+
+	v.Extra[key] = value
+}
+
+// SetUser непустые поля user дополняют уже найденного пользователя
+func (v *ContextValues) SetUser(user *UserForLog) {
+	if user == nil {
+		return
+	}
+
+	v.User = mergeUser(v.User, user)
+}
+
+// ContextExtractor достает из контекста теги, экстру и пользователя для сообщения.
+// Вызывается в фоновом воркере после TagsFromCtx, в порядке LoggerConfig.ContextExtractors
+type ContextExtractor interface {
+	Extract(ctx context.Context, values *ContextValues)
+}
+
+// ContextExtractorFunc функция как ContextExtractor
+type ContextExtractorFunc func(ctx context.Context, values *ContextValues)
+
+func (f ContextExtractorFunc) Extract(ctx context.Context, values *ContextValues) {
+	f(ctx, values)
+}
+
+// TagExtractor тег из значения контекста по ключу Key любого типа.
+// Поддерживаются строки, []byte как текст, fmt.Stringer, целые числа и UUID в виде [16]byte
+type TagExtractor struct {
+	Key interface{}
+	Tag string
+	// Default значение тега, если в контексте ничего нет, пустое значение тег не добавляет
+	Default string
+}
+
+func (e TagExtractor) Extract(ctx context.Context, values *ContextValues) {
+	if value, ok := tagValue(ctx.Value(e.Key)); ok {
+		values.SetTag(e.Tag, value)
+		return
+	}
+
+	if e.Default != "" {
+		values.SetTag(e.Tag, e.Default)
+	}
+}
+
+// ExtraExtractor значение контекста по ключу Key как есть попадает в экстру под именем Name
+type ExtraExtractor struct {
+	Key  interface{}
+	Name string
+}
+
+func (e ExtraExtractor) Extract(ctx context.Context, values *ContextValues) {
+	if value := ctx.Value(e.Key); value != nil {
+		values.SetExtra(e.Name, value)
+	}
+}
+
+// tagValue строковое представление значения контекста для тега
+func tagValue(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return val, true
+	case fmt.Stringer:
+		return val.String(), true
+	case []byte:
+		return string(val), true
+	case [16]byte:
+		return formatUUID(val), true
+	case int:
+		return strconv.FormatInt(int64(val), 10), true
+	case int8:
+		return strconv.FormatInt(int64(val), 10), true
+	case int16:
+		return strconv.FormatInt(int64(val), 10), true
+	case int32:
+		return strconv.FormatInt(int64(val), 10), true
+	case int64:
+		return strconv.FormatInt(val, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(val), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(val), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(val), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10), true
+	case uint64:
+		return strconv.FormatUint(val, 10), true
+	default:
+		return "", false
+	}
+}
+
+func formatUUID(u [16]byte) string {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf)
+}
+
+// NewUUID случайный UUID версии 4, например для request id
+func NewUUID() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return formatUUID(u)
+}
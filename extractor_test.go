@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type extractorKey struct{}
+
+func TestTagValue(t *testing.T) {
+	uuid := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+	tests := []struct {
+		value interface{}
+		want  string
+		ok    bool
+	}{
+		{nil, "", false},
+		{"s", "s", true},
+		{[]byte("raw"), "raw", true},
+		{uuid, "123e4567-e89b-12d3-a456-426614174000", true},
+		{net.IPv4(10, 0, 0, 1), "10.0.0.1", true},
+		{-7, "-7", true},
+		{int8(-8), "-8", true},
+		{int64(1) << 40, "1099511627776", true},
+		{uint8(255), "255", true},
+		{uint64(1) << 63, "9223372036854775808", true},
+		{1.5, "", false},
+		{struct{}{}, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tagValue(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("tagValue(%#v) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	a, b := NewUUID(), NewUUID()
+	if a == b {
+		t.Errorf("two equal UUIDs %s", a)
+	}
+
+	if len(a) != 36 || a[14] != '4' || a[8] != '-' || a[13] != '-' || a[18] != '-' || a[23] != '-' {
+		t.Errorf("%s is not a version 4 UUID", a)
+	}
+
+	if v := a[19]; v != '8' && v != '9' && v != 'a' && v != 'b' {
+		t.Errorf("%s has variant %c", a, v)
+	}
+}
+
+func TestContextExtractors(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{
+		Output: []LogDriver{d},
+		ContextExtractors: []ContextExtractor{
+			TagExtractor{Key: extractorKey{}, Tag: "tenant"},
+			TagExtractor{Key: "missing", Tag: "region", Default: "eu"},
+			TagExtractor{Key: "empty", Tag: "empty"},
+			ExtraExtractor{Key: extractorKey{}, Name: "tenant_id"},
+			ExtraExtractor{Key: "missing", Name: "missing"},
+			ContextExtractorFunc(func(ctx context.Context, values *ContextValues) {
+				values.SetUser(&UserForLog{Email: "a@b.c"})
+				values.SetUser(nil)
+			}),
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), extractorKey{}, 42)
+	ctx = ContextWithUser(ctx, &UserForLog{ID: "u1"})
+	ctx = ContextWithTag(ctx, "request", "r1")
+	l.NewLogEvent().Log(ctx, "msg")
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+
+	m := msgs[0]
+	if want := map[string]string{"request": "r1", "tenant": "42", "region": "eu"}; !reflect.DeepEqual(m.Tags, want) {
+		t.Errorf("tags %v, want %v", m.Tags, want)
+	}
+
+	if want := map[string]interface{}{"tenant_id": 42}; !reflect.DeepEqual(m.Extra, want) {
+		t.Errorf("extra %v, want %v", m.Extra, want)
+	}
+
+	if want := (&UserForLog{ID: "u1", Email: "a@b.c"}); !reflect.DeepEqual(m.User, want) {
+		t.Errorf("user %+v, want %+v", m.User, want)
+	}
+}
+
+func TestStringContextKeys(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "requestId", "r1")
+	ctx = context.WithValue(ctx, "userForLog", &UserForLog{ID: "u1"})
+
+	for _, disable := range []bool{false, true} {
+		d := &memDriver{}
+		l := newTestLogger(t, LoggerConfig{
+			Output:                   []LogDriver{d},
+			TagsFromCtx:              map[string]string{"requestId": "none"},
+			DisableStringContextKeys: disable,
+		})
+
+		l.NewLogEvent().Log(ctx, "msg")
+		// ContextWithTag и ContextWithUser работают в обоих режимах
+		l.NewLogEvent().Log(ContextWithUser(ContextWithTag(ctx, "requestId", "r2"), &UserForLog{ID: "u2"}), "typed")
+		shutdown(t, l)
+
+		msgs := d.messages()
+		if len(msgs) != 2 {
+			t.Fatalf("got %d messages, want 2", len(msgs))
+		}
+
+		wantTag, wantUser := "r1", &UserForLog{ID: "u1"}
+		if disable {
+			wantTag, wantUser = "none", nil
+		}
+
+		if got := msgs[0].Tags["requestId"]; got != wantTag {
+			t.Errorf("DisableStringContextKeys %v: requestId %q, want %q", disable, got, wantTag)
+		}
+
+		if !reflect.DeepEqual(msgs[0].User, wantUser) {
+			t.Errorf("DisableStringContextKeys %v: user %+v, want %+v", disable, msgs[0].User, wantUser)
+		}
+
+		if msgs[1].Tags["requestId"] != "r2" || msgs[1].User == nil || msgs[1].User.ID != "u2" {
+			t.Errorf("DisableStringContextKeys %v: typed context values lost: %v %+v", disable, msgs[1].Tags, msgs[1].User)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mailru/easyjson"
+	"github.com/mailru/easyjson/jlexer"
+	"github.com/mailru/easyjson/jwriter"
+)
+
+// FieldType тип значения поля, по нему выбирается способ кодирования
+type FieldType uint8
+
+const (
+	UnknownType FieldType = iota
+	// SkipType поле не выводится, например Err(nil)
+	SkipType
+	StringType
+	Int64Type
+	Uint64Type
+	Float64Type
+	BoolType
+	DurationType
+	TimeType
+	ErrorType
+	// ObjectType значение реализует easyjson.Marshaler
+	ObjectType
+	// AnyType значение кодируется через encoding/json с рефлексией
+	AnyType
+)
+
+// Field типизированное поле сообщения. Создается функциями String, Int64, Duration, Err, Object, Any и т.д.
+type Field struct {
+	Key  string
+	Type FieldType
+
+	integer int64
+	str     string
+	iface   interface{}
+}
+
+// Fields поля сообщения, кодируются в json объект без рефлексии
+type Fields []Field
+
+func String(key, val string) Field {
+	return Field{Key: key, Type: StringType, str: val}
+}
+
+func Int(key string, val int) Field {
+	return Int64(key, int64(val))
+}
+
+func Int64(key string, val int64) Field {
+	return Field{Key: key, Type: Int64Type, integer: val}
+}
+
+func Uint64(key string, val uint64) Field {
+	return Field{Key: key, Type: Uint64Type, integer: int64(val)}
+}
+
+func Float64(key string, val float64) Field {
+	return Field{Key: key, Type: Float64Type, integer: int64(math.Float64bits(val))}
+}
+
+func Bool(key string, val bool) Field {
+	var i int64
+	if val {
+		i = 1
+	}
+
+	return Field{Key: key, Type: BoolType, integer: i}
+}
+
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, Type: DurationType, integer: int64(val)}
+}
+
+func Time(key string, val time.Time) Field {
+	return Field{Key: key, Type: TimeType, iface: val}
+}
+
+// Err поле "error" с текстом ошибки, для nil ошибки поле не выводится
+func Err(err error) Field {
+	return NamedErr("error", err)
+}
+
+func NamedErr(key string, err error) Field {
+	if err == nil {
+		return Field{Key: key, Type: SkipType}
+	}
+
+	return Field{Key: key, Type: ErrorType, iface: err}
+}
+
+// Object поле со значением, которое умеет кодировать себя через easyjson
+func Object(key string, val easyjson.Marshaler) Field {
+	return Field{Key: key, Type: ObjectType, iface: val}
+}
+
+// Any выбирает типизированное поле по типу значения, для остальных типов используется encoding/json
+func Any(key string, val interface{}) Field {
+	switch v := val.(type) {
+	case string:
+		return String(key, v)
+	case int:
+		return Int64(key, int64(v))
+	case int8:
+		return Int64(key, int64(v))
+	case int16:
+		return Int64(key, int64(v))
+	case int32:
+		return Int64(key, int64(v))
+	case int64:
+		return Int64(key, v)
+	case uint:
+		return Uint64(key, uint64(v))
+	case uint8:
+		return Uint64(key, uint64(v))
+	case uint16:
+		return Uint64(key, uint64(v))
+	case uint32:
+		return Uint64(key, uint64(v))
+	case uint64:
+		return Uint64(key, v)
+	case float32:
+		return Float64(key, float64(v))
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Duration:
+		return Duration(key, v)
+	case time.Time:
+		return Time(key, v)
+	case error:
+		return NamedErr(key, v)
+	case easyjson.Marshaler:
+		return Object(key, v)
+	default:
+		return Field{Key: key, Type: AnyType, iface: val}
+	}
+}
+
+// Value значение поля для драйверов, которым нужен interface{}, например для экстры sentry
+func (f Field) Value() interface{} {
+	switch f.Type {
+	case StringType, TagType:
+		return f.str
+	case Int64Type:
+		return f.integer
+	case Uint64Type:
+		return uint64(f.integer)
+	case Float64Type:
+		return math.Float64frombits(uint64(f.integer))
+	case BoolType:
+		return f.integer == 1
+	case DurationType:
+		return time.Duration(f.integer).String()
+	case TimeType:
+		return f.iface.(time.Time).Format(time.RFC3339Nano)
+	case ErrorType:
+		return f.iface.(error).Error()
+	case ObjectType:
+		b, err := easyjson.Marshal(f.iface.(easyjson.Marshaler))
+		if err != nil {
+			return fmt.Sprintf("marshal error: %s", err.Error())
+		}
+		return json.RawMessage(b)
+	case SkipType:
+		return nil
+	default:
+		return f.iface
+	}
+}
+
+// skipped поле не выводится: SkipType, а также User и Request, которые имеют смысл только для With
+func (f Field) skipped() bool {
+	return f.Type == SkipType || f.Type == UserType || f.Type == RequestType
+}
+
+func (f Field) encode(w *jwriter.Writer) {
+	switch f.Type {
+	case StringType, TagType:
+		w.String(f.str)
+	case Int64Type:
+		w.Int64(f.integer)
+	case Uint64Type:
+		w.Uint64(uint64(f.integer))
+	case Float64Type:
+		w.Float64(math.Float64frombits(uint64(f.integer)))
+	case BoolType:
+		w.Bool(f.integer == 1)
+	case DurationType:
+		w.String(time.Duration(f.integer).String())
+	case TimeType:
+		w.String(f.iface.(time.Time).Format(time.RFC3339Nano))
+	case ErrorType:
+		w.String(f.iface.(error).Error())
+	case ObjectType:
+		f.iface.(easyjson.Marshaler).MarshalEasyJSON(w)
+	default:
+		b, err := json.Marshal(f.iface)
+		if err != nil {
+			w.String(fmt.Sprintf("marshal error: %s", err.Error()))
+			return
+		}
+		w.Raw(b, nil)
+	}
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (fs Fields) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawByte('{')
+	first := true
+	for _, f := range fs {
+		if f.skipped() {
+			continue
+		}
+
+		if !first {
+			w.RawByte(',')
+		}
+		first = false
+
+		w.String(f.Key)
+		w.RawByte(':')
+		f.encode(w)
+	}
+	w.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (fs Fields) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	fs.MarshalEasyJSON(&w)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface, значения восстанавливаются через Any
+func (fs *Fields) UnmarshalEasyJSON(in *jlexer.Lexer) {
+	if in.IsNull() {
+		in.Skip()
+		*fs = nil
+		return
+	}
+
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		*fs = append(*fs, Any(key, in.Interface()))
+		in.WantComma()
+	}
+	in.Delim('}')
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (fs *Fields) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	fs.UnmarshalEasyJSON(&r)
+	return r.Error()
+}
+
+// Map поля в виде карты, для драйверов, которым нужен map[string]interface{}
+func (fs Fields) Map() map[string]interface{} {
+	res := make(map[string]interface{}, len(fs))
+	for _, f := range fs {
+		if f.skipped() {
+			continue
+		}
+		res[f.Key] = f.Value()
+	}
+
+	return res
+}
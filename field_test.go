@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailru/easyjson"
+)
+
+func TestFieldsEncoding(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 5, time.UTC)
+
+	tests := []struct {
+		field Field
+		want  string
+	}{
+		{String("k", `a "quoted" string`), `{"k":"a \"quoted\" string"}`},
+		{Int("k", -42), `{"k":-42}`},
+		{Int64("k", math.MinInt64), `{"k":-9223372036854775808}`},
+		{Uint64("k", math.MaxUint64), `{"k":18446744073709551615}`},
+		{Float64("k", 1.5), `{"k":1.5}`},
+		{Bool("k", true), `{"k":true}`},
+		{Bool("k", false), `{"k":false}`},
+		{Duration("k", 1500*time.Millisecond), `{"k":"1.5s"}`},
+		{Time("k", ts), `{"k":"2024-03-01T12:00:00.000000005Z"}`},
+		{Err(errors.New("boom")), `{"error":"boom"}`},
+		{Err(nil), `{}`},
+		{NamedErr("k", nil), `{}`},
+		{Object("k", &UserForLog{ID: "u1"}), `{"k":{"id":"u1"}}`},
+		{Any("k", map[string]int{"a": 1}), `{"k":{"a":1}}`},
+		{Any("k", func() {}), `{"k":"marshal error: json: unsupported type: func()"}`},
+		{Tag("k", "v"), `{"k":"v"}`},
+		{Extra("k", []int{1, 2}), `{"k":[1,2]}`},
+		{User(&UserForLog{ID: "u1"}), `{}`},
+		{Request(httptest.NewRequest("GET", "/", nil)), `{}`},
+	}
+
+	for _, tt := range tests {
+		b, err := json.Marshal(Fields{tt.field})
+		if err != nil {
+			t.Errorf("type %d: %v", tt.field.Type, err)
+			continue
+		}
+
+		if string(b) != tt.want {
+			t.Errorf("type %d: got %s, want %s", tt.field.Type, b, tt.want)
+		}
+	}
+}
+
+func TestFieldsSkippedBetween(t *testing.T) {
+	b, err := json.Marshal(Fields{Err(nil), String("a", "1"), Err(nil), Int("b", 2), User(&UserForLog{}), Err(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{"a":"1","b":2}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestAnyPicksType(t *testing.T) {
+	tests := []struct {
+		val  interface{}
+		want FieldType
+	}{
+		{"s", StringType},
+		{int8(1), Int64Type},
+		{int32(1), Int64Type},
+		{uint16(1), Uint64Type},
+		{float32(1), Float64Type},
+		{true, BoolType},
+		{time.Second, DurationType},
+		{time.Now(), TimeType},
+		{errors.New("e"), ErrorType},
+		{error(nil), AnyType},
+		{&UserForLog{}, ObjectType},
+		{[]string{"a"}, AnyType},
+	}
+
+	for _, tt := range tests {
+		if got := Any("k", tt.val).Type; got != tt.want {
+			t.Errorf("Any(%T) type %d, want %d", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestFieldsRoundTrip(t *testing.T) {
+	fields := Fields{
+		String("s", "v"),
+		Int("i", 42),
+		Float64("f", 0.25),
+		Bool("b", true),
+		Duration("d", time.Second),
+		Err(errors.New("boom")),
+		Err(nil),
+		Any("a", []string{"x"}),
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Fields
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// числа восстанавливаются как float64, строковые значения как String
+	want := map[string]interface{}{
+		"s":     "v",
+		"i":     float64(42),
+		"f":     0.25,
+		"b":     true,
+		"d":     "1s",
+		"error": "boom",
+		"a":     []interface{}{"x"},
+	}
+	if !reflect.DeepEqual(got.Map(), want) {
+		t.Errorf("got %v, want %v", got.Map(), want)
+	}
+
+	again, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(again) != string(b) {
+		t.Errorf("second encoding %s differs from %s", again, b)
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	msg := Message{
+		ServiceName: "svc",
+		Time:        "2024-03-01T12:00:00Z",
+		Seq:         7,
+		MessageType: "LOG",
+		Data:        "text",
+		Tags:        map[string]string{"t": "v"},
+		Extra:       map[string]interface{}{"e": "x"},
+		Fields:      Fields{String("s", "v"), Bool("b", true)},
+		User:        &UserForLog{ID: "u1", Email: "a@b.c"},
+	}
+
+	b, err := easyjson.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// easyjson и encoding/json дают одинаковый документ
+	std, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != string(std) {
+		t.Errorf("easyjson %s, encoding/json %s", b, std)
+	}
+
+	var got Message
+	if err := easyjson.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ServiceName != msg.ServiceName || got.Time != msg.Time || got.Seq != msg.Seq ||
+		got.MessageType != msg.MessageType || got.Data != msg.Data {
+		t.Errorf("got %+v, want %+v", got, msg)
+	}
+
+	if !reflect.DeepEqual(got.Tags, msg.Tags) || !reflect.DeepEqual(got.Extra, msg.Extra) || !reflect.DeepEqual(got.User, msg.User) {
+		t.Errorf("tags, extra or user changed: %+v", got)
+	}
+
+	if !reflect.DeepEqual(got.Fields.Map(), msg.Fields.Map()) {
+		t.Errorf("fields %v, want %v", got.Fields.Map(), msg.Fields.Map())
+	}
+
+	if strings.Contains(string(b), "stacktrace") || strings.Contains(string(b), "trace_id") {
+		t.Errorf("empty optional fields encoded: %s", b)
+	}
+}
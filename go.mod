@@ -5,4 +5,8 @@ go 1.15
 require (
 	github.com/getsentry/sentry-go v0.6.1
 	github.com/mailru/easyjson v0.7.2
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
+	golang.org/x/sys v0.0.0-20200323222414-85ca7c5b95cd
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 )
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LevelName возвращает название уровня, например "DEBUG"
+func LevelName(level int) string {
+	code, ok := levelSlug[level]
+	if !ok {
+		return "UNKNOWN"
+	}
+
+	return code
+}
+
+// ParseLevel возвращает уровень по названию без учета регистра
+func ParseLevel(name string) (int, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for level, code := range levelSlug {
+		if code == name {
+			return level, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// Level текущий уровень логирования
+func (l *Logger) Level() int {
+	return int(atomic.LoadInt32(&l.level))
+}
+
+// SetLevel меняет уровень логирования и отменяет временный уровень, выставленный SetLevelFor
+func (l *Logger) SetLevel(level int) {
+	l.levelMu.Lock()
+	defer l.levelMu.Unlock()
+
+	if l.levelTimer != nil {
+		l.levelTimer.Stop()
+		l.levelTimer = nil
+	}
+	l.levelExpires = time.Time{}
+
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+// SetLevelFor меняет уровень логирования на время ttl, после чего возвращает уровень,
+// который был до первого временного изменения
+func (l *Logger) SetLevelFor(level int, ttl time.Duration) {
+	if ttl <= 0 {
+		l.SetLevel(level)
+		return
+	}
+
+	l.levelMu.Lock()
+	defer l.levelMu.Unlock()
+
+	if l.levelTimer != nil {
+		l.levelTimer.Stop()
+	} else {
+		l.baseLevel = l.Level()
+	}
+
+	atomic.StoreInt32(&l.level, int32(level))
+	l.levelExpires = time.Now().Add(ttl)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl, func() {
+		l.levelMu.Lock()
+		defer l.levelMu.Unlock()
+
+		// уровень успели поменять еще раз
+		if l.levelTimer != timer {
+			return
+		}
+
+		atomic.StoreInt32(&l.level, int32(l.baseLevel))
+		l.levelTimer = nil
+		l.levelExpires = time.Time{}
+	})
+	l.levelTimer = timer
+}
+
+func (l *Logger) levelExpiresAt() time.Time {
+	l.levelMu.Lock()
+	defer l.levelMu.Unlock()
+
+	return l.levelExpires
+}
+
+type levelRequest struct {
+	Level string `json:"level"`
+	TTL   string `json:"ttl,omitempty"`
+}
+
+type levelResponse struct {
+	Level     string `json:"level,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// LevelHandler http.Handler для просмотра и изменения уровня логирования.
+//
+// GET возвращает текущий уровень: {"level":"LOG"}.
+// PUT меняет уровень: {"level":"DEBUG","ttl":"10m"} в теле или ?level=DEBUG&ttl=10m в запросе,
+// ttl необязателен, по его истечении уровень возвращается к прежнему
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			err := l.setLevelFromRequest(r)
+			if err != nil {
+				writeLevelResponse(w, http.StatusBadRequest, levelResponse{Error: err.Error()})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeLevelResponse(w, http.StatusMethodNotAllowed, levelResponse{Error: "method not allowed"})
+			return
+		}
+
+		res := levelResponse{Level: LevelName(l.Level())}
+		if expires := l.levelExpiresAt(); !expires.IsZero() {
+			res.ExpiresAt = expires.UTC().Format(time.RFC3339)
+		}
+
+		writeLevelResponse(w, http.StatusOK, res)
+	})
+}
+
+func (l *Logger) setLevelFromRequest(r *http.Request) error {
+	req := levelRequest{
+		Level: r.URL.Query().Get("level"),
+		TTL:   r.URL.Query().Get("ttl"),
+	}
+
+	if req.Level == "" {
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return fmt.Errorf("invalid request body: %s", err.Error())
+		}
+	}
+
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid ttl: %s", err.Error())
+		}
+	}
+
+	l.SetLevelFor(level, ttl)
+
+	return nil
+}
+
+func writeLevelResponse(w http.ResponseWriter, status int, res levelResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
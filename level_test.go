@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitLevel ждет, пока уровень логгера станет level
+func waitLevel(t *testing.T, l *Logger, level int, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for l.Level() != level {
+		if time.Now().After(deadline) {
+			t.Fatalf("level is %s, want %s", LevelName(l.Level()), LevelName(level))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetLevelForReverts(t *testing.T) {
+	l := newTestLogger(t, LoggerConfig{Level: LOG})
+	defer shutdown(t, l)
+
+	l.SetLevelFor(DEBUG, 30*time.Millisecond)
+	if l.Level() != DEBUG {
+		t.Fatalf("level is %s, want DEBUG", LevelName(l.Level()))
+	}
+
+	if l.levelExpiresAt().IsZero() {
+		t.Error("temporary level has no expiry")
+	}
+
+	waitLevel(t, l, LOG, time.Second)
+
+	if !l.levelExpiresAt().IsZero() {
+		t.Error("expiry kept after revert")
+	}
+}
+
+func TestSetLevelForKeepsOriginalLevel(t *testing.T) {
+	l := newTestLogger(t, LoggerConfig{Level: LOG})
+	defer shutdown(t, l)
+
+	l.SetLevelFor(DEBUG, 30*time.Millisecond)
+	l.SetLevelFor(TRACE, 30*time.Millisecond)
+
+	// возвращается уровень до первого временного изменения, а не DEBUG
+	waitLevel(t, l, LOG, time.Second)
+}
+
+func TestSetLevelCancelsRevert(t *testing.T) {
+	l := newTestLogger(t, LoggerConfig{Level: LOG})
+	defer shutdown(t, l)
+
+	l.SetLevelFor(DEBUG, 20*time.Millisecond)
+	l.SetLevel(ERROR)
+
+	time.Sleep(50 * time.Millisecond)
+	if l.Level() != ERROR {
+		t.Errorf("level is %s, want ERROR", LevelName(l.Level()))
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	l := newTestLogger(t, LoggerConfig{Level: LOG})
+	defer shutdown(t, l)
+	h := l.LevelHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"debug","ttl":"30ms"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status %d: %s", w.Code, w.Body)
+	}
+
+	var res levelResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Level != "DEBUG" || res.ExpiresAt == "" {
+		t.Errorf("unexpected response %+v", res)
+	}
+
+	waitLevel(t, l, LOG, time.Second)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/?level=nope", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown level status %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status %d, want 405", w.Code)
+	}
+}
@@ -1,29 +1,68 @@
 package logger
 
+//go:generate easyjson logger.go
+
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
 	"net/http"
-	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const flushPollInterval = 5 * time.Millisecond
+
 type Logger struct {
-	Config    LoggerConfig
-	Msg       chan blankMsg
-	logDone   chan bool
-	logCancel chan bool
+	// pending сообщения, принятые в Msg, но еще не разложенные по очередям драйверов
+	pending int64
+	// seq порядковый номер последнего сообщения
+	seq uint64
+	// level текущий уровень, Config.Level используется только как начальное значение
+	level int32
+
+	Config LoggerConfig
+	Msg    chan blankMsg
+	queues []*driverQueue
+
+	mu           sync.RWMutex
+	closed       bool
+	closing      chan struct{}
+	abort        chan struct{}
+	done         chan struct{}
+	shutdownOnce sync.Once
+	abortOnce    sync.Once
+	closeOnce    sync.Once
+
+	levelMu      sync.Mutex
+	levelTimer   *time.Timer
+	levelExpires time.Time
+	baseLevel    int
+
+	dropped       *levelCounter
+	driverDropped *levelCounter
+	sampler       *sampler
+	dedup         *dedup
+	filtered      *levelCounter
 }
 
 type messages struct {
 	Level int
 	Msg   Message
+
+	caller uintptr
 }
 
 //easyjson:json
 type Message struct {
 	ServiceName string                 `json:"service_name"`
+	Logger      string                 `json:"logger,omitempty"`
 	Time        string                 `json:"date"`
+	Seq         uint64                 `json:"seq,omitempty"`
+	TraceID     string                 `json:"trace_id,omitempty"`
+	SpanID      string                 `json:"span_id,omitempty"`
+	TraceFlags  string                 `json:"trace_flags,omitempty"`
 	MessageType string                 `json:"message_type"`
 	Trace       string                 `json:"trace,omitempty"`
 	Source      string                 `json:"source,omitempty"`
@@ -31,9 +70,37 @@ type Message struct {
 	Data        interface{}            `json:"data"`
 	Tags        map[string]string      `json:"tags,omitempty"`
 	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Fields      Fields                 `json:"fields,omitempty"`
 	User        *UserForLog            `json:"user,omitempty"`
 	Request     *http.Request          `json:"-"`
 	Ctx         context.Context        `json:"-"`
+	Timestamp   time.Time              `json:"-"`
+	PCs         []uintptr              `json:"-"`
+
+	lazyStack *lazyStacktrace
+}
+
+// DataText текстовое представление данных сообщения: строки, []byte, error и fmt.Stringer как есть,
+// остальное в JSON, nil пустая строка
+func DataText(data interface{}) string {
+	switch d := data.(type) {
+	case nil:
+		return ""
+	case string:
+		return d
+	case []byte:
+		return string(d)
+	case error:
+		return d.Error()
+	case fmt.Stringer:
+		return d.String()
+	}
+
+	if b, err := json.Marshal(data); err == nil {
+		return string(b)
+	}
+
+	return fmt.Sprint(data)
 }
 
 //easyjson:json
@@ -45,11 +112,14 @@ type UserForLog struct {
 }
 
 type blankMsg struct {
-	level      int
-	data       interface{}
-	stack      []byte
-	Stacktrace *Stacktrace
-	ctx        context.Context
+	level     int
+	data      interface{}
+	pcs       []uintptr
+	stackMode StackMode
+	ctx       context.Context
+	time      time.Time
+	seq       uint64
+	caller    uintptr
 
 	mutator messageMutator
 }
@@ -64,20 +134,64 @@ var levelSlug = map[int]string{
 
 // GetLogger получение инстанса логгера
 func GetLogger(config LoggerConfig) (*Logger, error) {
+	if err := checkOverflow(config); err != nil {
+		return nil, err
+	}
+
 	l := &Logger{}
+	drivers := make([]DriverConfig, 0, len(config.Output)+len(config.Drivers))
 	for _, ld := range config.Output {
-		err := ld.Init()
-		if err != nil {
-			return nil, err
-		}
+		drivers = append(drivers, DriverConfig{Driver: ld})
 	}
+	drivers = append(drivers, config.Drivers...)
+
 	l.Config = config
+	l.level = int32(config.Level)
 
 	if l.Config.NeedToLog == nil {
 		l.Config.NeedToLog = defaultNeedToLogDeterminant
 	}
-	l.logDone = make(chan bool)
-	l.logCancel = make(chan bool)
+
+	if l.Config.ErrorHandler == nil {
+		l.Config.ErrorHandler = defaultErrorHandler
+	}
+
+	for _, dc := range drivers {
+		if r, ok := dc.Driver.(ErrorReporter); ok {
+			r.SetErrorHandler(l.asyncErrorHandler(dc.Driver, dc.Fallback))
+		}
+
+		err := dc.Driver.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		if dc.Fallback != nil {
+			if r, ok := dc.Fallback.(ErrorReporter); ok {
+				r.SetErrorHandler(l.asyncErrorHandler(dc.Fallback, nil))
+			}
+
+			err = dc.Fallback.Init()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	l.dropped = &levelCounter{}
+	l.driverDropped = &levelCounter{}
+	l.filtered = &levelCounter{}
+	l.sampler = newSampler(config.Sampling)
+	l.dedup = newDedup(config.DedupWindow, l.formatTime)
+	l.closing = make(chan struct{})
+	l.abort = make(chan struct{})
+	l.done = make(chan struct{})
+
+	for _, dc := range drivers {
+		q := newDriverQueue(dc, l)
+		q.start()
+		l.queues = append(l.queues, q)
+	}
 
 	in := make(chan blankMsg, config.Buffer)
 	l.Msg = in
@@ -86,109 +200,238 @@ func GetLogger(config LoggerConfig) (*Logger, error) {
 	return l, nil
 }
 
-func (l *Logger) Shutdown() {
-	l.logCancel <- true
-	<- l.logDone
+// Flush ждет доставки всех принятых сообщений и сбрасывает буферы драйверов, реализующих Flusher.
+// Если ctx истекает раньше, возвращает *FlushError с количеством недоставленных сообщений
+func (l *Logger) Flush(ctx context.Context) error {
+	t := time.NewTicker(flushPollInterval)
+	defer t.Stop()
+
+	for l.undelivered() > 0 {
+		select {
+		case <-ctx.Done():
+			return &FlushError{Lost: l.undelivered(), err: ctx.Err()}
+		case <-t.C:
+		}
+	}
+
+	return l.flushDrivers(ctx, false)
+}
+
+// Shutdown перестает принимать сообщения, доставляет оставшиеся и закрывает драйверы.
+// Close вызывается, даже если Flush драйвера вернул ошибку, возвращается первая ошибка.
+// Если ctx истекает раньше, возвращает *FlushError с количеством потерянных сообщений,
+// а драйверы закрываются в фоне с тем же истекшим ctx, когда воркеры закончат текущие вызовы PutMsg.
+// Повторный вызов безопасен, логирование после Shutdown ничего не делает
+func (l *Logger) Shutdown(ctx context.Context) error {
+	l.shutdownOnce.Do(func() {
+		close(l.closing)
+
+		l.mu.Lock()
+		l.closed = true
+		close(l.Msg)
+		l.mu.Unlock()
+	})
+
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		l.abortOnce.Do(func() {
+			close(l.abort)
+
+			go func() {
+				<-l.done
+				l.closeDrivers(ctx)
+			}()
+		})
+		return &FlushError{Lost: l.undelivered(), err: ctx.Err()}
+	}
+
+	return l.closeDrivers(ctx)
 }
 
-func (l *Logger) logProcess() {
-	in := make(chan blankMsg, l.Config.Buffer)
-	go func() {
-		for {
-			select {
-			case <-l.logCancel:
-				close(in)
-				return
-			case m := <-l.Msg:
-				in <- m
+// closeDrivers сбрасывает и закрывает драйверы один раз, повторные вызовы возвращают nil
+func (l *Logger) closeDrivers(ctx context.Context) error {
+	var err error
+	l.closeOnce.Do(func() {
+		err = l.flushDrivers(ctx, true)
+	})
+
+	return err
+}
+
+func (l *Logger) undelivered() int64 {
+	n := atomic.LoadInt64(&l.pending)
+	for _, q := range l.queues {
+		n += atomic.LoadInt64(&q.pending)
+	}
+
+	return n
+}
+
+func (l *Logger) flushDrivers(ctx context.Context, close bool) error {
+	var res error
+	for _, q := range l.queues {
+		for _, driver := range []LogDriver{q.driver, q.fallback} {
+			if driver == nil {
+				continue
+			}
+
+			var err error
+			if f, ok := driver.(Flusher); ok {
+				err = f.Flush(ctx)
+			}
+
+			if c, ok := driver.(Closer); ok && close {
+				if cerr := c.Close(ctx); err == nil {
+					err = cerr
+				}
+			}
+
+			if err != nil && res == nil {
+				res = err
 			}
 		}
-	}()
+	}
+
+	return res
+}
+
+func (l *Logger) logProcess() {
 	go func() {
-		defer func() {
-			l.logDone <- true
-		}()
-		l.logging(in)
+		defer close(l.done)
+		l.logging(l.Msg)
+
+		for _, q := range l.queues {
+			q.stop()
+		}
 	}()
 }
 
 func (l *Logger) logging(in chan blankMsg) {
-	for msg := range in {
-		for _, driver := range l.Config.Output {
-			m := l.genMessage(msg.ctx, msg.level, msg.stack, msg.Stacktrace, msg.data)
+	var tick <-chan time.Time
+	if l.dedup != nil {
+		t := time.NewTicker(l.dedup.interval())
+		defer t.Stop()
+		tick = t.C
+	}
 
-			if msg.mutator != nil {
-				m = msg.mutator.mutate(m)
+	for {
+		select {
+		case msg, ok := <-in:
+			if !ok {
+				if l.dedup != nil {
+					l.fanOut(l.dedup.expire(time.Now(), true)...)
+				}
+				return
 			}
 
-			err := driver.PutMsg(m.Msg)
+			l.dispatch(msg)
+			atomic.AddInt64(&l.pending, -1)
+		case now := <-tick:
+			l.fanOut(l.dedup.expire(now, false)...)
+		}
+	}
+}
 
-			if err != nil {
-				log.Fatalln(err)
-			}
+func (l *Logger) dispatch(msg blankMsg) {
+	m := l.genMessage(msg)
+
+	if msg.mutator != nil {
+		m = msg.mutator.mutate(m)
+	}
+
+	if !l.process(msg.ctx, &m) {
+		return
+	}
+
+	if l.dedup != nil {
+		pass, summary := l.dedup.check(m)
+		l.fanOut(summary...)
+		if !pass {
+			return
 		}
 	}
+
+	l.fanOut(m)
 }
 
-func (l *Logger) logMessage(msg blankMsg) {
-	select {
-	case l.Msg <- msg:
-	case <-time.After(time.Microsecond * 20):
-		ch := make(chan blankMsg)
-		go l.logging(ch)
-		defer close(ch)
+func (l *Logger) fanOut(ms ...messages) {
+	for _, m := range ms {
+		for _, q := range l.queues {
+			q.enqueue(m)
+		}
 	}
 }
 
-func (l *Logger) genMessage(ctx context.Context, level int, stack []byte, stacktrace *Stacktrace, data interface{}) messages {
+func (l *Logger) genMessage(bm blankMsg) messages {
+	ctx := bm.ctx
+	level := bm.level
+	data := bm.data
+
 	code, ok := levelSlug[level]
 
 	if !ok {
 		code = "UNKNOWN"
 	}
 
-	var userForLog *UserForLog
-	userForLogValue := ctx.Value("userForLog")
-	if userForLogValue != nil {
-		user, ok := userForLogValue.(*UserForLog)
-		if ok {
-			userForLog = user
-		}
+	userForLog := l.extractUserFromCtx(ctx)
+
+	var trace string
+	var stacktrace *Stacktrace
+	var lazyStack *lazyStacktrace
+	switch bm.stackMode {
+	case StackFrames:
+		trace = formatTrace(bm.pcs)
+		stacktrace = newStacktraceFromPCs(bm.pcs)
+	case StackPCs:
+		lazyStack = &lazyStacktrace{pcs: bm.pcs}
 	}
 
-	trace := string(stack)
-
 	if err, ok := data.(error); ok {
 		data = err.Error()
 	}
 
-	tags := l.extractTagsFromCtx(ctx)
+	values := ContextValues{
+		Tags: l.extractTagsFromCtx(ctx),
+		User: userForLog,
+	}
+	for _, extractor := range l.Config.ContextExtractors {
+		extractor.Extract(ctx, &values)
+	}
 
 	msg := messages{
-		Level: level,
+		Level:  level,
+		caller: bm.caller,
 		Msg: Message{
 			ServiceName: l.Config.ServiceName,
-			Time:        time.Now().UTC().Format("2006-01-02 15:04:05"),
+			Time:        l.formatTime(bm.time),
+			Seq:         bm.seq,
+			Timestamp:   bm.time,
 			MessageType: code,
 			Data:        data,
-			Tags:        tags,
+			Tags:        values.Tags,
+			Extra:       values.Extra,
 			Trace:       trace,
 			Stacktrace:  stacktrace,
+			PCs:         bm.pcs,
+			lazyStack:   lazyStack,
 			Ctx:         ctx,
-			User:        userForLog,
+			User:        values.User,
 		},
 	}
 
+	setSpanContext(&msg.Msg)
+
 	return msg
 }
 
 func (l *Logger) extractTagsFromCtx(ctx context.Context) map[string]string {
-	res := make(map[string]string, len(l.Config.TagsFromCtx))
+	ctxTags := tagsFromContext(ctx)
+	res := make(map[string]string, len(l.Config.TagsFromCtx)+len(ctxTags))
 	for key, def := range l.Config.TagsFromCtx {
 		resValue := def
-		tmpVal := ctx.Value(key)
-		if tmpVal != nil {
-			valString, ok := tmpVal.(string)
+		if !l.Config.DisableStringContextKeys {
+			valString, ok := tagValue(ctx.Value(key))
 			if ok {
 				resValue = valString
 			}
@@ -197,6 +440,10 @@ func (l *Logger) extractTagsFromCtx(ctx context.Context) map[string]string {
 		res[string(key)] = resValue
 	}
 
+	for key, value := range ctxTags {
+		res[key] = value
+	}
+
 	return res
 }
 
@@ -205,33 +452,87 @@ type messageMutator interface {
 }
 
 type LogEvent struct {
-	l       *Logger
+	l        *Logger
+	name     string
+	level    int
+	hasLevel bool
+
 	Source  string                 `json:"source,omitempty"`
 	Tags    map[string]string      `json:"tags,omitempty"`
 	Extra   map[string]interface{} `json:"extra,omitempty"`
+	Fields  Fields                 `json:"fields,omitempty"`
 	User    *UserForLog            `json:"user,omitempty"`
 	Request *http.Request          `json:"-"`
 }
 
 func (e *LogEvent) mutate(m messages) messages {
-	if m.Msg.Tags == nil {
-		m.Msg.Tags = e.Tags
-	} else {
-		for k, v := range e.Tags {
-			m.Msg.Tags[k] = v
-		}
+	if m.Msg.Tags == nil && len(e.Tags) > 0 {
+		m.Msg.Tags = make(map[string]string, len(e.Tags))
+	}
+	for k, v := range e.Tags {
+		m.Msg.Tags[k] = v
 	}
 
-	m.Msg.Extra = e.Extra
-	m.Msg.Request = e.Request
+	if m.Msg.Extra == nil && len(e.Extra) > 0 {
+		m.Msg.Extra = make(map[string]interface{}, len(e.Extra))
+	}
+	for k, v := range e.Extra {
+		m.Msg.Extra[k] = v
+	}
+
+	m.Msg.Fields = append(m.Msg.Fields, e.Fields...)
+
+	if e.Request != nil {
+		m.Msg.Request = e.Request
+	}
 
 	if e.User != nil {
-		m.Msg.User = e.User
+		m.Msg.User = mergeUser(m.Msg.User, e.User)
 	}
 
+	m.Msg.Logger = e.name
+
 	return m
 }
 
+// clone копия события со своими картами тегов и экстры и своим пользователем
+func (e *LogEvent) clone() *LogEvent {
+	c := *e
+
+	if e.User != nil {
+		user := *e.User
+		c.User = &user
+	}
+
+	if e.Tags != nil {
+		c.Tags = make(map[string]string, len(e.Tags))
+		for k, v := range e.Tags {
+			c.Tags[k] = v
+		}
+	}
+
+	if e.Extra != nil {
+		c.Extra = make(map[string]interface{}, len(e.Extra))
+		for k, v := range e.Extra {
+			c.Extra[k] = v
+		}
+	}
+
+	// append в копию не должен писать в общий массив
+	c.Fields = e.Fields[:len(e.Fields):len(e.Fields)]
+
+	return &c
+}
+
+// configuredLevel уровень логирования с учетом LevelOverrides для имени события
+func (e *LogEvent) configuredLevel() int {
+	if e.hasLevel {
+		return e.level
+	}
+
+	return e.l.Level()
+}
+
 func (e *LogEvent) GetTags() map[string]string {
 	return e.Tags
 }
@@ -240,6 +541,10 @@ func (e *LogEvent) GetExtra() map[string]interface{} {
 	return e.Extra
 }
 
+func (e *LogEvent) GetFields() Fields {
+	return e.Fields
+}
+
 func (e *LogEvent) GetUser() *UserForLog {
 	return e.User
 }
@@ -276,6 +581,11 @@ func (e *LogEvent) WithExtra(k string, v interface{}) *LogEvent {
 	return e
 }
 
+func (e *LogEvent) WithFields(fields ...Field) *LogEvent {
+	e.apply(fields)
+	return e
+}
+
 func (e *LogEvent) WithRequest(r *http.Request) *LogEvent {
 	e.Request = r
 	return e
@@ -287,17 +597,54 @@ func (e *LogEvent) WithUser(user *UserForLog) *LogEvent {
 }
 
 func (e *LogEvent) log(ctx context.Context, level int, data interface{}) {
-	if e.l.Config.NeedToLog(ctx, e.l.Config.Level, level) {
-		stack := debug.Stack()
+	e.logPCs(ctx, level, data, nil)
+}
+
+// logPCs логирует с заранее снятым стеком pcs, если pcs == nil, стек снимается в месте вызова
+func (e *LogEvent) logPCs(ctx context.Context, level int, data interface{}, pcs []uintptr) {
+	// событие без логгера из FromContext
+	if e.l == nil {
+		return
+	}
+
+	if e.l.Config.NeedToLog(ctx, e.configuredLevel(), level) {
+		var caller uintptr
+		if e.l.sampler.enabled(level) || e.l.dedup != nil {
+			caller = callSite(pcs)
+		}
+
+		if e.l.sampler.enabled(level) && !e.l.sampler.check(level, caller, data) {
+			return
+		}
+
+		now := time.Now()
+		mode := e.l.stackMode(level)
+
+		if mode == StackNone {
+			pcs = nil
+		} else if pcs == nil {
+			// стек начинается с вызывающего, без logPCs, log и Alert/Error/...
+			pcs = callers(3)
+		}
+
 		bm := blankMsg{
-			level:      level,
-			data:       data,
-			stack:      stack,
-			Stacktrace: NewStacktrace(),
-			ctx:        ctx,
-			mutator:    e,
+			level:     level,
+			data:      data,
+			pcs:       pcs,
+			stackMode: mode,
+			ctx:       ctx,
+			time:      now,
+			seq:       atomic.AddUint64(&e.l.seq, 1),
+			caller:    caller,
+			// снимок на момент вызова: воркер читает его, пока вызывающий может дальше менять событие
+			mutator: e.clone(),
+		}
+
+		if e.l.Config.SpanEvents {
+			e.addSpanEvent(bm)
 		}
-		e.l.logMessage(bm)
+
+		e.l.enqueue(bm)
 	}
 }
 
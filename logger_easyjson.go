@@ -17,7 +17,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson22b64118DecodeGithubComDKolpakovLogger(in *jlexer.Lexer, out *UserForLog) {
+func easyjson22b64118DecodeGithubComDKolpakovLoggerV2(in *jlexer.Lexer, out *UserForLog) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -54,7 +54,7 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger(in *jlexer.Lexer, out *UserF
 		in.Consumed()
 	}
 }
-func easyjson22b64118EncodeGithubComDKolpakovLogger(out *jwriter.Writer, in UserForLog) {
+func easyjson22b64118EncodeGithubComDKolpakovLoggerV2(out *jwriter.Writer, in UserForLog) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -100,27 +100,27 @@ func easyjson22b64118EncodeGithubComDKolpakovLogger(out *jwriter.Writer, in User
 // MarshalJSON supports json.Marshaler interface
 func (v UserForLog) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson22b64118EncodeGithubComDKolpakovLogger(&w, v)
+	easyjson22b64118EncodeGithubComDKolpakovLoggerV2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v UserForLog) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson22b64118EncodeGithubComDKolpakovLogger(w, v)
+	easyjson22b64118EncodeGithubComDKolpakovLoggerV2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *UserForLog) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson22b64118DecodeGithubComDKolpakovLogger(&r, v)
+	easyjson22b64118DecodeGithubComDKolpakovLoggerV2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *UserForLog) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson22b64118DecodeGithubComDKolpakovLogger(l, v)
+	easyjson22b64118DecodeGithubComDKolpakovLoggerV2(l, v)
 }
-func easyjson22b64118DecodeGithubComDKolpakovLogger1(in *jlexer.Lexer, out *Message) {
+func easyjson22b64118DecodeGithubComDKolpakovLoggerV21(in *jlexer.Lexer, out *Message) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -141,8 +141,18 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger1(in *jlexer.Lexer, out *Mess
 		switch key {
 		case "service_name":
 			out.ServiceName = string(in.String())
+		case "logger":
+			out.Logger = string(in.String())
 		case "date":
 			out.Time = string(in.String())
+		case "seq":
+			out.Seq = uint64(in.Uint64())
+		case "trace_id":
+			out.TraceID = string(in.String())
+		case "span_id":
+			out.SpanID = string(in.String())
+		case "trace_flags":
+			out.TraceFlags = string(in.String())
 		case "message_type":
 			out.MessageType = string(in.String())
 		case "trace":
@@ -157,7 +167,7 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger1(in *jlexer.Lexer, out *Mess
 				if out.Stacktrace == nil {
 					out.Stacktrace = new(Stacktrace)
 				}
-				easyjson22b64118DecodeGithubComDKolpakovLogger2(in, out.Stacktrace)
+				easyjson22b64118DecodeGithubComDKolpakovLoggerV22(in, out.Stacktrace)
 			}
 		case "data":
 			if m, ok := out.Data.(easyjson.Unmarshaler); ok {
@@ -213,6 +223,8 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger1(in *jlexer.Lexer, out *Mess
 				}
 				in.Delim('}')
 			}
+		case "fields":
+			(out.Fields).UnmarshalEasyJSON(in)
 		case "user":
 			if in.IsNull() {
 				in.Skip()
@@ -233,7 +245,7 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger1(in *jlexer.Lexer, out *Mess
 		in.Consumed()
 	}
 }
-func easyjson22b64118EncodeGithubComDKolpakovLogger1(out *jwriter.Writer, in Message) {
+func easyjson22b64118EncodeGithubComDKolpakovLoggerV21(out *jwriter.Writer, in Message) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -242,11 +254,36 @@ func easyjson22b64118EncodeGithubComDKolpakovLogger1(out *jwriter.Writer, in Mes
 		out.RawString(prefix[1:])
 		out.String(string(in.ServiceName))
 	}
+	if in.Logger != "" {
+		const prefix string = ",\"logger\":"
+		out.RawString(prefix)
+		out.String(string(in.Logger))
+	}
 	{
 		const prefix string = ",\"date\":"
 		out.RawString(prefix)
 		out.String(string(in.Time))
 	}
+	if in.Seq != 0 {
+		const prefix string = ",\"seq\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Seq))
+	}
+	if in.TraceID != "" {
+		const prefix string = ",\"trace_id\":"
+		out.RawString(prefix)
+		out.String(string(in.TraceID))
+	}
+	if in.SpanID != "" {
+		const prefix string = ",\"span_id\":"
+		out.RawString(prefix)
+		out.String(string(in.SpanID))
+	}
+	if in.TraceFlags != "" {
+		const prefix string = ",\"trace_flags\":"
+		out.RawString(prefix)
+		out.String(string(in.TraceFlags))
+	}
 	{
 		const prefix string = ",\"message_type\":"
 		out.RawString(prefix)
@@ -265,7 +302,7 @@ func easyjson22b64118EncodeGithubComDKolpakovLogger1(out *jwriter.Writer, in Mes
 	if in.Stacktrace != nil {
 		const prefix string = ",\"stacktrace\":"
 		out.RawString(prefix)
-		easyjson22b64118EncodeGithubComDKolpakovLogger2(out, *in.Stacktrace)
+		easyjson22b64118EncodeGithubComDKolpakovLoggerV22(out, *in.Stacktrace)
 	}
 	{
 		const prefix string = ",\"data\":"
@@ -322,6 +359,11 @@ func easyjson22b64118EncodeGithubComDKolpakovLogger1(out *jwriter.Writer, in Mes
 			out.RawByte('}')
 		}
 	}
+	if len(in.Fields) != 0 {
+		const prefix string = ",\"fields\":"
+		out.RawString(prefix)
+		(in.Fields).MarshalEasyJSON(out)
+	}
 	if in.User != nil {
 		const prefix string = ",\"user\":"
 		out.RawString(prefix)
@@ -333,27 +375,27 @@ func easyjson22b64118EncodeGithubComDKolpakovLogger1(out *jwriter.Writer, in Mes
 // MarshalJSON supports json.Marshaler interface
 func (v Message) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson22b64118EncodeGithubComDKolpakovLogger1(&w, v)
+	easyjson22b64118EncodeGithubComDKolpakovLoggerV21(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Message) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson22b64118EncodeGithubComDKolpakovLogger1(w, v)
+	easyjson22b64118EncodeGithubComDKolpakovLoggerV21(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Message) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson22b64118DecodeGithubComDKolpakovLogger1(&r, v)
+	easyjson22b64118DecodeGithubComDKolpakovLoggerV21(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Message) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson22b64118DecodeGithubComDKolpakovLogger1(l, v)
+	easyjson22b64118DecodeGithubComDKolpakovLoggerV21(l, v)
 }
-func easyjson22b64118DecodeGithubComDKolpakovLogger2(in *jlexer.Lexer, out *Stacktrace) {
+func easyjson22b64118DecodeGithubComDKolpakovLoggerV22(in *jlexer.Lexer, out *Stacktrace) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -389,7 +431,7 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger2(in *jlexer.Lexer, out *Stac
 				}
 				for !in.IsDelim(']') {
 					var v5 Frame
-					easyjson22b64118DecodeGithubComDKolpakovLogger3(in, &v5)
+					easyjson22b64118DecodeGithubComDKolpakovLoggerV23(in, &v5)
 					out.Frames = append(out.Frames, v5)
 					in.WantComma()
 				}
@@ -428,7 +470,7 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger2(in *jlexer.Lexer, out *Stac
 		in.Consumed()
 	}
 }
-func easyjson22b64118EncodeGithubComDKolpakovLogger2(out *jwriter.Writer, in Stacktrace) {
+func easyjson22b64118EncodeGithubComDKolpakovLoggerV22(out *jwriter.Writer, in Stacktrace) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -442,7 +484,7 @@ func easyjson22b64118EncodeGithubComDKolpakovLogger2(out *jwriter.Writer, in Sta
 				if v7 > 0 {
 					out.RawByte(',')
 				}
-				easyjson22b64118EncodeGithubComDKolpakovLogger3(out, v8)
+				easyjson22b64118EncodeGithubComDKolpakovLoggerV23(out, v8)
 			}
 			out.RawByte(']')
 		}
@@ -468,7 +510,7 @@ func easyjson22b64118EncodeGithubComDKolpakovLogger2(out *jwriter.Writer, in Sta
 	}
 	out.RawByte('}')
 }
-func easyjson22b64118DecodeGithubComDKolpakovLogger3(in *jlexer.Lexer, out *Frame) {
+func easyjson22b64118DecodeGithubComDKolpakovLoggerV23(in *jlexer.Lexer, out *Frame) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -589,7 +631,7 @@ func easyjson22b64118DecodeGithubComDKolpakovLogger3(in *jlexer.Lexer, out *Fram
 		in.Consumed()
 	}
 }
-func easyjson22b64118EncodeGithubComDKolpakovLogger3(out *jwriter.Writer, in Frame) {
+func easyjson22b64118EncodeGithubComDKolpakovLoggerV23(out *jwriter.Writer, in Frame) {
 	out.RawByte('{')
 	first := true
 	_ = first
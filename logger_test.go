@@ -0,0 +1,268 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memDriver запоминает сообщения, err и delay задают ошибку и задержку PutMsg,
+// block держит PutMsg до закрытия, entered получает сигнал о входе в PutMsg
+type memDriver struct {
+	mu      sync.Mutex
+	msgs    []Message
+	calls   int
+	err     error
+	delay   time.Duration
+	block   chan struct{}
+	entered chan struct{}
+}
+
+func (d *memDriver) Init() error {
+	return nil
+}
+
+func (d *memDriver) PutMsg(msg Message) error {
+	if d.entered != nil {
+		select {
+		case d.entered <- struct{}{}:
+		default:
+		}
+	}
+
+	if d.block != nil {
+		<-d.block
+	}
+
+	if d.delay > 0 {
+		time.Sleep(d.delay)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.calls++
+	if d.err != nil {
+		return d.err
+	}
+
+	d.msgs = append(d.msgs, msg)
+
+	return nil
+}
+
+func (d *memDriver) messages() []Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]Message(nil), d.msgs...)
+}
+
+func (d *memDriver) callCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.calls
+}
+
+func messageData(msgs []Message) []interface{} {
+	res := make([]interface{}, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, m.Data)
+	}
+
+	return res
+}
+
+func newTestLogger(t *testing.T, config LoggerConfig) *Logger {
+	t.Helper()
+
+	if config.Level == 0 {
+		config.Level = TRACE
+	}
+
+	l, err := GetLogger(config)
+	if err != nil {
+		t.Fatalf("GetLogger: %v", err)
+	}
+
+	return l
+}
+
+func shutdown(t *testing.T, l *Logger) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := l.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestLoggerDeliversToAllDrivers(t *testing.T) {
+	d1, d2 := &memDriver{}, &memDriver{}
+	l := newTestLogger(t, LoggerConfig{ServiceName: "svc", Output: []LogDriver{d1, d2}})
+
+	l.NewLogEvent().Error(context.Background(), "boom")
+	shutdown(t, l)
+
+	for _, d := range []*memDriver{d1, d2} {
+		msgs := d.messages()
+		if len(msgs) != 1 {
+			t.Fatalf("got %d messages, want 1", len(msgs))
+		}
+
+		if msgs[0].Data != "boom" || msgs[0].MessageType != "ERROR" || msgs[0].ServiceName != "svc" {
+			t.Errorf("unexpected message %+v", msgs[0])
+		}
+	}
+}
+
+func TestLoggerAfterShutdown(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+	shutdown(t, l)
+
+	l.NewLogEvent().Error(context.Background(), errors.New("late"))
+
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+
+	if n := len(d.messages()); n != 0 {
+		t.Errorf("got %d messages after Shutdown, want 0", n)
+	}
+}
+
+// closeDriver memDriver, который запоминает вызовы Flush и Close, flushErr возвращает Flush
+type closeDriver struct {
+	memDriver
+	flushed, closed int32
+	flushErr        error
+}
+
+func (d *closeDriver) Flush(ctx context.Context) error {
+	atomic.AddInt32(&d.flushed, 1)
+	return d.flushErr
+}
+
+func (d *closeDriver) Close(ctx context.Context) error {
+	atomic.AddInt32(&d.closed, 1)
+	return nil
+}
+
+func TestShutdownFlushesAndClosesDrivers(t *testing.T) {
+	d := &closeDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+
+	for i := 0; i < 10; i++ {
+		l.NewLogEvent().Log(context.Background(), i)
+	}
+	shutdown(t, l)
+
+	if n := len(d.messages()); n != 10 {
+		t.Errorf("got %d messages, want 10", n)
+	}
+
+	if atomic.LoadInt32(&d.flushed) != 1 || atomic.LoadInt32(&d.closed) != 1 {
+		t.Errorf("Flush called %d times, Close %d times, want 1 and 1", d.flushed, d.closed)
+	}
+}
+
+func TestShutdownDeadlineReportsLost(t *testing.T) {
+	d := &closeDriver{memDriver: memDriver{block: make(chan struct{}), entered: make(chan struct{}, 1)}}
+	l := newTestLogger(t, LoggerConfig{Buffer: 10, Output: []LogDriver{d}})
+
+	ev := l.NewLogEvent()
+	for i := 0; i < 5; i++ {
+		ev.Log(context.Background(), i)
+	}
+	<-d.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := l.Shutdown(ctx)
+
+	var fe *FlushError
+	if !errors.As(err, &fe) {
+		t.Fatalf("Shutdown returned %v, want *FlushError", err)
+	}
+
+	if fe.Lost != 5 {
+		t.Errorf("Lost = %d, want 5", fe.Lost)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v does not wrap context.DeadlineExceeded", err)
+	}
+
+	if atomic.LoadInt32(&d.closed) != 0 {
+		t.Error("driver closed while messages are still undelivered")
+	}
+
+	// драйвер закрывается в фоне, когда воркер закончит с очередью
+	close(d.block)
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&d.closed) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("driver was not closed after the workers finished")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShutdownClosesAfterFlushError(t *testing.T) {
+	failure := errors.New("flush failed")
+	d1 := &closeDriver{flushErr: failure}
+	d2 := &closeDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d1, d2}})
+
+	if err := l.Shutdown(context.Background()); err != failure {
+		t.Errorf("Shutdown returned %v, want the flush error", err)
+	}
+
+	for i, d := range []*closeDriver{d1, d2} {
+		if atomic.LoadInt32(&d.closed) != 1 {
+			t.Errorf("driver %d was not closed", i)
+		}
+	}
+
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown returned %v", err)
+	}
+
+	if atomic.LoadInt32(&d1.closed) != 1 {
+		t.Error("second Shutdown closed the driver again")
+	}
+}
+
+func TestFlushDeadlineReportsLost(t *testing.T) {
+	d := &memDriver{block: make(chan struct{}), entered: make(chan struct{}, 1)}
+	l := newTestLogger(t, LoggerConfig{Buffer: 10, Output: []LogDriver{d}})
+
+	ev := l.NewLogEvent()
+	for i := 0; i < 3; i++ {
+		ev.Log(context.Background(), i)
+	}
+	<-d.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var fe *FlushError
+	if err := l.Flush(ctx); !errors.As(err, &fe) || fe.Lost != 3 {
+		t.Fatalf("Flush returned %v, want *FlushError with 3 lost", err)
+	}
+
+	close(d.block)
+	shutdown(t, l)
+
+	if n := len(d.messages()); n != 3 {
+		t.Errorf("got %d messages after Flush timeout, want 3", n)
+	}
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryClientInterceptor передает теги логгера из контекста в исходящие metadata и логирует вызов
+func UnaryClientInterceptor(opts Options) grpc.UnaryClientInterceptor {
+	opts.setDefaults()
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		start := time.Now()
+
+		var p peer.Peer
+		callOpts = append(callOpts, grpc.Peer(&p))
+
+		err := invoker(opts.outgoingContext(ctx), method, req, reply, cc, callOpts...)
+		opts.logCall(ctx, "client_unary", method, &p, err, time.Since(start))
+
+		return err
+	}
+}
+
+// StreamClientInterceptor передает теги логгера из контекста в исходящие metadata и логирует вызов,
+// когда поток закончился: RecvMsg вернул io.EOF или ошибку, или ответ на поток без ServerStreams.
+// Если поток не удалось открыть, вызов логируется сразу
+func StreamClientInterceptor(opts Options) grpc.StreamClientInterceptor {
+	opts.setDefaults()
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+
+		p := &peer.Peer{}
+		callOpts = append(callOpts, grpc.Peer(p))
+
+		cs, err := streamer(opts.outgoingContext(ctx), desc, cc, method, callOpts...)
+		if err != nil {
+			opts.logCall(ctx, "client_stream", method, p, err, time.Since(start))
+			return cs, err
+		}
+
+		return &clientStream{
+			ClientStream:  cs,
+			opts:          &opts,
+			ctx:           ctx,
+			method:        method,
+			peer:          p,
+			start:         start,
+			serverStreams: desc.ServerStreams,
+		}, nil
+	}
+}
+
+// clientStream логирует вызов один раз, когда поток закончился
+type clientStream struct {
+	grpc.ClientStream
+	opts          *Options
+	ctx           context.Context
+	method        string
+	peer          *peer.Peer
+	start         time.Time
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	switch {
+	case err == io.EOF:
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	case !s.serverStreams:
+		// единственный ответ сервера, других сообщений не будет
+		s.finish(nil)
+	}
+
+	return err
+}
+
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		s.opts.logCall(s.ctx, "client_stream", s.method, s.peer, err, time.Since(s.start))
+	})
+}
@@ -0,0 +1,13 @@
+module github.com/d-kolpakov/logger/v2/middleware/grpc
+
+go 1.15
+
+// Модулю нужны NewUUID, ContextWithTags, NewContext, FromContext, With и ReportPanic,
+// которых нет в v2.0.0, поэтому сначала выпускается тег логгера v2.1.0, потом middleware/grpc/v2.1.0.
+// replace нужен только для разработки внутри репозитория и не действует для зависимых модулей
+require (
+	github.com/d-kolpakov/logger/v2 v2.1.0
+	google.golang.org/grpc v1.43.0
+)
+
+replace github.com/d-kolpakov/logger/v2 => ../..
@@ -0,0 +1,180 @@
+// Package grpc серверные и клиентские перехватчики gRPC: request id и account id из metadata в контексте логгера,
+// лог вызовов и перехват паник с логированием ALERT. Это отдельный модуль, чтобы основной модуль логгера не зависел от gRPC,
+// ему нужен логгер v2.1.0 или новее
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/d-kolpakov/logger/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultRequestIDKey = "x-request-id"
+	defaultRequestIDTag = "requestId"
+	defaultAccountIDKey = "x-account-id"
+	defaultAccountIDTag = "accountId"
+)
+
+type Options struct {
+	// Logger событие, от которого пишутся вызовы и паники, например l.Named("grpc"), по умолчанию logger.FromContext.
+	// Серверные перехватчики кладут его в контекст вызова для logger.FromContext в обработчиках
+	Logger *logger.LogEvent
+	// RequestIDKey ключ metadata с request id, по умолчанию x-request-id
+	RequestIDKey string
+	// RequestIDTag тег для request id, по умолчанию requestId
+	RequestIDTag string
+	// GenerateRequestID генератор request id для запросов без него, по умолчанию случайный UUID
+	GenerateRequestID func() string
+	// AccountIDKey ключ metadata с account id, по умолчанию x-account-id
+	AccountIDKey string
+	// AccountIDTag тег для account id, по умолчанию accountId
+	AccountIDTag string
+	// ForwardTags теги контекста, которые клиентские перехватчики передают в исходящие metadata: тег -> ключ metadata.
+	// По умолчанию RequestIDTag и AccountIDTag в RequestIDKey и AccountIDKey
+	ForwardTags map[string]string
+	// DisableCallLog не писать лог вызовов
+	DisableCallLog bool
+}
+
+func (opts *Options) setDefaults() {
+	if opts.RequestIDKey == "" {
+		opts.RequestIDKey = defaultRequestIDKey
+	}
+
+	if opts.RequestIDTag == "" {
+		opts.RequestIDTag = defaultRequestIDTag
+	}
+
+	if opts.GenerateRequestID == nil {
+		opts.GenerateRequestID = logger.NewUUID
+	}
+
+	if opts.AccountIDKey == "" {
+		opts.AccountIDKey = defaultAccountIDKey
+	}
+
+	if opts.AccountIDTag == "" {
+		opts.AccountIDTag = defaultAccountIDTag
+	}
+
+	if opts.ForwardTags == nil {
+		opts.ForwardTags = map[string]string{
+			opts.RequestIDTag: opts.RequestIDKey,
+			opts.AccountIDTag: opts.AccountIDKey,
+		}
+	}
+}
+
+// incomingContext контекст с тегами из входящих metadata
+func (opts *Options) incomingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := first(md, opts.RequestIDKey)
+	if requestID == "" {
+		requestID = opts.GenerateRequestID()
+	}
+
+	tags := map[string]string{
+		opts.RequestIDTag: requestID,
+	}
+
+	if accountID := first(md, opts.AccountIDKey); accountID != "" {
+		tags[opts.AccountIDTag] = accountID
+	}
+
+	ctx = logger.ContextWithTags(ctx, tags)
+
+	return logger.NewContext(ctx, opts.logger(ctx))
+}
+
+func (opts *Options) logger(ctx context.Context) *logger.LogEvent {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+
+	return logger.FromContext(ctx)
+}
+
+// outgoingContext контекст с тегами логгера в исходящих metadata
+func (opts *Options) outgoingContext(ctx context.Context) context.Context {
+	tags := logger.TagsFromContext(ctx)
+
+	pairs := make([]string, 0, 2*len(opts.ForwardTags))
+	for tag, key := range opts.ForwardTags {
+		if value, ok := tags[tag]; ok && value != "" {
+			pairs = append(pairs, key, value)
+		}
+	}
+
+	if len(pairs) == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func (opts *Options) logCall(ctx context.Context, kind, method string, p *peer.Peer, err error, duration time.Duration) {
+	if opts.DisableCallLog {
+		return
+	}
+
+	code := status.Code(err)
+
+	fields := []logger.Field{
+		logger.String("grpc.kind", kind),
+		logger.String("grpc.method", method),
+		logger.String("grpc.code", code.String()),
+		logger.Duration("grpc.duration", duration),
+	}
+	if p != nil && p.Addr != nil {
+		fields = append(fields, logger.String("grpc.peer", p.Addr.String()))
+	}
+	if err != nil {
+		fields = append(fields, logger.Err(err))
+	}
+
+	ev := opts.logger(ctx).With(fields...)
+	msg := fmt.Sprintf("%s %s %s", kind, method, code.String())
+
+	if isServerError(code) {
+		ev.Error(ctx, msg)
+		return
+	}
+
+	ev.Log(ctx, msg)
+}
+
+// recovered логирует панику как ALERT со стеком паникующего обработчика и возвращает ошибку codes.Internal для клиента.
+// Вызывается из deferred функции перехватчика
+func (opts *Options) recovered(ctx context.Context, method string, rec interface{}) error {
+	logger.ReportPanic(ctx, rec, logger.RecoverOptions{
+		Logger: opts.logger(ctx).With(logger.String("grpc.method", method)),
+	})
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+func first(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
@@ -0,0 +1,305 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d-kolpakov/logger/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type memDriver struct {
+	mu   sync.Mutex
+	msgs []logger.Message
+}
+
+func (d *memDriver) Init() error {
+	return nil
+}
+
+func (d *memDriver) PutMsg(msg logger.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.msgs = append(d.msgs, msg)
+
+	return nil
+}
+
+// find сообщения, текст которых начинается с prefix
+func (d *memDriver) find(prefix string) []logger.Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var res []logger.Message
+	for _, m := range d.msgs {
+		if s, ok := m.Data.(string); ok && strings.HasPrefix(s, prefix) {
+			res = append(res, m)
+		}
+	}
+
+	return res
+}
+
+// testServer сервис test.Test на сообщениях grpc_health_v1, чтобы не генерировать код из proto.
+// Check паникует для Service == "panic"
+type testServer struct {
+	mu   sync.Mutex
+	tags map[string]string
+}
+
+func (s *testServer) check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if in.Service == "panic" {
+		panic("boom")
+	}
+
+	s.mu.Lock()
+	s.tags = logger.TagsFromContext(ctx)
+	s.mu.Unlock()
+
+	logger.FromContext(ctx).Log(ctx, "in handler")
+
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *testServer) handlerTags() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tags
+}
+
+var testServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Test",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Check",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(healthpb.HealthCheckRequest)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(*testServer).check(ctx, req.(*healthpb.HealthCheckRequest))
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+
+			return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Test/Check"}, handler)
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Watch",
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			in := new(healthpb.HealthCheckRequest)
+			if err := stream.RecvMsg(in); err != nil {
+				return err
+			}
+
+			for i := 0; i < 3; i++ {
+				if err := stream.SendMsg(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}},
+}
+
+var watchDesc = &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+
+func newTestConn(t *testing.T) (*grpc.ClientConn, *testServer, *memDriver, *logger.Logger) {
+	t.Helper()
+
+	d := &memDriver{}
+	l, err := logger.GetLogger(logger.LoggerConfig{Level: logger.TRACE, Output: []logger.LogDriver{d}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Logger: l.NewLogEvent()}
+	lis := bufconn.Listen(1 << 20)
+	ts := &testServer{}
+
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(opts)),
+		grpc.StreamInterceptor(StreamServerInterceptor(opts)),
+	)
+	s.RegisterService(&testServiceDesc, ts)
+	go s.Serve(lis)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(opts)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+		l.Shutdown(context.Background())
+	})
+
+	return conn, ts, d, l
+}
+
+func flush(t *testing.T, l *logger.Logger) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := l.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagsForwardedToServer(t *testing.T) {
+	conn, ts, d, l := newTestConn(t)
+
+	ctx := logger.ContextWithTags(context.Background(), map[string]string{
+		"requestId": "req-1",
+		"accountId": "acc-1",
+		"other":     "not forwarded",
+	})
+
+	resp := new(healthpb.HealthCheckResponse)
+	if err := conn.Invoke(ctx, "/test.Test/Check", &healthpb.HealthCheckRequest{}, resp); err != nil {
+		t.Fatal(err)
+	}
+	flush(t, l)
+
+	want := map[string]string{"requestId": "req-1", "accountId": "acc-1"}
+	if got := ts.handlerTags(); len(got) != 2 || got["requestId"] != "req-1" || got["accountId"] != "acc-1" {
+		t.Errorf("handler tags %v, want %v", got, want)
+	}
+
+	for _, prefix := range []string{"in handler", "unary /test.Test/Check OK", "client_unary /test.Test/Check OK"} {
+		msgs := d.find(prefix)
+		if len(msgs) != 1 {
+			t.Fatalf("got %d messages %q, want 1", len(msgs), prefix)
+		}
+
+		if msgs[0].Tags["requestId"] != "req-1" || msgs[0].Tags["accountId"] != "acc-1" {
+			t.Errorf("message %q has tags %v", prefix, msgs[0].Tags)
+		}
+	}
+}
+
+func TestGeneratedRequestID(t *testing.T) {
+	conn, ts, _, _ := newTestConn(t)
+
+	resp := new(healthpb.HealthCheckResponse)
+	if err := conn.Invoke(context.Background(), "/test.Test/Check", &healthpb.HealthCheckRequest{}, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if id := ts.handlerTags()["requestId"]; len(id) != 36 {
+		t.Errorf("generated request id %q is not a UUID", id)
+	}
+}
+
+func TestPanicBecomesInternal(t *testing.T) {
+	conn, _, d, l := newTestConn(t)
+
+	resp := new(healthpb.HealthCheckResponse)
+	err := conn.Invoke(context.Background(), "/test.Test/Check", &healthpb.HealthCheckRequest{Service: "panic"}, resp)
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("got %v, want codes.Internal", err)
+	}
+	flush(t, l)
+
+	var alerts []logger.Message
+	for _, m := range d.find("") {
+		if m.MessageType == "ALERT" {
+			alerts = append(alerts, m)
+		}
+	}
+
+	if len(alerts) != 1 {
+		t.Fatalf("got %d ALERT messages, want 1", len(alerts))
+	}
+
+	if s, _ := alerts[0].Data.(string); !strings.Contains(s, "boom") {
+		t.Errorf("ALERT does not mention the panic: %v", alerts[0].Data)
+	}
+
+	if len(alerts[0].PCs) == 0 {
+		t.Fatal("ALERT has no stack")
+	}
+
+	if f, _ := runtime.CallersFrames(alerts[0].PCs).Next(); !strings.HasSuffix(f.Function, "(*testServer).check") {
+		t.Errorf("ALERT stack starts at %s, want the panicking handler", f.Function)
+	}
+
+	if n := len(d.find("unary /test.Test/Check Internal")); n != 1 {
+		t.Errorf("got %d call logs with Internal, want 1", n)
+	}
+}
+
+func TestClientStreamLoggedAtEnd(t *testing.T) {
+	conn, _, d, l := newTestConn(t)
+
+	ctx := logger.ContextWithTag(context.Background(), "requestId", "req-2")
+	cs, err := conn.NewStream(ctx, watchDesc, "/test.Test/Watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cs.SendMsg(&healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := cs.RecvMsg(new(healthpb.HealthCheckResponse)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flush(t, l)
+
+	if n := len(d.find("client_stream")); n != 0 {
+		t.Fatalf("client stream logged before it finished")
+	}
+
+	if err := cs.RecvMsg(new(healthpb.HealthCheckResponse)); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+	cs.RecvMsg(new(healthpb.HealthCheckResponse))
+	flush(t, l)
+
+	msgs := d.find("client_stream /test.Test/Watch OK")
+	if len(msgs) != 1 {
+		t.Fatalf("got %d client_stream logs, want 1", len(msgs))
+	}
+
+	if msgs[0].Tags["requestId"] != "req-2" {
+		t.Errorf("client_stream log tags %v", msgs[0].Tags)
+	}
+
+	if n := len(d.find("stream /test.Test/Watch OK")); n != 1 {
+		t.Errorf("got %d server stream logs, want 1", n)
+	}
+}
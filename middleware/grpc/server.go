@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// UnaryServerInterceptor переносит request id и account id из metadata в контекст логгера,
+// логирует вызов и превращает панику обработчика в ALERT и codes.Internal
+func UnaryServerInterceptor(opts Options) grpc.UnaryServerInterceptor {
+	opts.setDefaults()
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		start := time.Now()
+		ctx = opts.incomingContext(ctx)
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = opts.recovered(ctx, info.FullMethod, rec)
+			}
+
+			p, _ := peer.FromContext(ctx)
+			opts.logCall(ctx, "unary", info.FullMethod, p, err, time.Since(start))
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor то же, что UnaryServerInterceptor, для потоковых вызовов
+func StreamServerInterceptor(opts Options) grpc.StreamServerInterceptor {
+	opts.setDefaults()
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		ctx := opts.incomingContext(ss.Context())
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = opts.recovered(ctx, info.FullMethod, rec)
+			}
+
+			p, _ := peer.FromContext(ctx)
+			opts.logCall(ctx, "stream", info.FullMethod, p, err, time.Since(start))
+		}()
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream поток с контекстом, в котором есть теги логгера
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,160 @@
+// Package http middleware для net/http: request id, теги и пользователь в контексте,
+// access log и перехват паник с логированием ALERT
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/d-kolpakov/logger/v2"
+)
+
+const (
+	defaultRequestIDHeader = "X-Request-Id"
+	defaultRequestIDTag    = "requestId"
+	defaultSourceHeader    = "X-Source"
+	defaultSourceTag       = "source"
+)
+
+type Options struct {
+	// Logger событие, от которого пишутся access log и паники, например l.Named("http"),
+	// по умолчанию logger.FromContext. Оно же кладется в контекст запроса для logger.FromContext в обработчиках
+	Logger *logger.LogEvent
+	// RequestIDHeader заголовок с request id, по умолчанию X-Request-Id.
+	// Если в запросе его нет, id генерируется и возвращается в ответе
+	RequestIDHeader string
+	// RequestIDTag тег для request id, по умолчанию requestId
+	RequestIDTag string
+	// GenerateRequestID генератор request id, по умолчанию случайный UUID
+	GenerateRequestID func() string
+	// SourceHeader заголовок с именем вызывающего сервиса, по умолчанию X-Source
+	SourceHeader string
+	// SourceTag тег для SourceHeader, по умолчанию source
+	SourceTag string
+	// Tags дополнительные теги запроса
+	Tags func(r *http.Request) map[string]string
+	// User пользователь запроса для сообщений
+	User func(r *http.Request) *logger.UserForLog
+	// DisableAccessLog не писать access log
+	DisableAccessLog bool
+	// SkipAccessLog не писать access log для отдельных запросов, например health check
+	SkipAccessLog func(r *http.Request) bool
+}
+
+// Middleware заполняет контекст запроса тегами и пользователем для логгера, пишет access log
+// и превращает паники обработчика в ALERT с приложенным *http.Request
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	opts.setDefaults()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(opts.RequestIDHeader)
+			if requestID == "" {
+				requestID = opts.GenerateRequestID()
+			}
+			w.Header().Set(opts.RequestIDHeader, requestID)
+
+			ev := opts.Logger
+			if ev == nil {
+				ev = logger.FromContext(r.Context())
+			}
+
+			ctx := logger.ContextWithTags(r.Context(), opts.tags(r, requestID))
+			if opts.User != nil {
+				if user := opts.User(r); user != nil {
+					ctx = logger.ContextWithUser(ctx, user)
+				}
+			}
+			ctx = logger.NewContext(ctx, ev)
+			r = r.WithContext(ctx)
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			defer func() {
+				if rec := recover(); rec != nil {
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
+
+					logger.ReportPanic(ctx, rec, logger.RecoverOptions{Logger: ev.With(logger.Request(r))})
+
+					if !rw.wroteHeader {
+						rw.WriteHeader(http.StatusInternalServerError)
+					}
+				}
+
+				opts.accessLog(ctx, ev, r, rw, time.Since(start))
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+func (opts *Options) setDefaults() {
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = defaultRequestIDHeader
+	}
+
+	if opts.RequestIDTag == "" {
+		opts.RequestIDTag = defaultRequestIDTag
+	}
+
+	if opts.GenerateRequestID == nil {
+		opts.GenerateRequestID = logger.NewUUID
+	}
+
+	if opts.SourceHeader == "" {
+		opts.SourceHeader = defaultSourceHeader
+	}
+
+	if opts.SourceTag == "" {
+		opts.SourceTag = defaultSourceTag
+	}
+}
+
+func (opts *Options) tags(r *http.Request, requestID string) map[string]string {
+	tags := map[string]string{
+		opts.RequestIDTag: requestID,
+	}
+
+	if source := r.Header.Get(opts.SourceHeader); source != "" {
+		tags[opts.SourceTag] = source
+	}
+
+	if opts.Tags != nil {
+		for k, v := range opts.Tags(r) {
+			tags[k] = v
+		}
+	}
+
+	return tags
+}
+
+func (opts *Options) accessLog(ctx context.Context, ev *logger.LogEvent, r *http.Request, rw *responseWriter, latency time.Duration) {
+	if opts.DisableAccessLog || (opts.SkipAccessLog != nil && opts.SkipAccessLog(r)) {
+		return
+	}
+
+	ev = ev.With(
+		logger.String("method", r.Method),
+		logger.String("path", r.URL.Path),
+		logger.Int("status", rw.status),
+		logger.Int64("bytes", rw.bytes),
+		logger.Duration("latency", latency),
+		logger.String("remote_addr", r.RemoteAddr),
+		logger.String("user_agent", r.UserAgent()),
+	)
+
+	msg := fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, rw.status)
+	if rw.status >= http.StatusInternalServerError {
+		ev.Error(ctx, msg)
+		return
+	}
+
+	ev.Log(ctx, msg)
+}
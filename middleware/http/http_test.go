@@ -0,0 +1,241 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d-kolpakov/logger/v2"
+)
+
+type memDriver struct {
+	mu   sync.Mutex
+	msgs []logger.Message
+}
+
+func (d *memDriver) Init() error {
+	return nil
+}
+
+func (d *memDriver) PutMsg(msg logger.Message) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.msgs = append(d.msgs, msg)
+
+	return nil
+}
+
+// byType сообщения уровня messageType
+func (d *memDriver) byType(messageType string) []logger.Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var res []logger.Message
+	for _, m := range d.msgs {
+		if m.MessageType == messageType {
+			res = append(res, m)
+		}
+	}
+
+	return res
+}
+
+// serve пропускает один запрос через Middleware с логгером в памяти и дожидается доставки сообщений
+func serve(t *testing.T, opts Options, r *http.Request, h http.HandlerFunc) (*httptest.ResponseRecorder, *memDriver) {
+	t.Helper()
+
+	d := &memDriver{}
+	l, err := logger.GetLogger(logger.LoggerConfig{Level: logger.TRACE, Output: []logger.LogDriver{d}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := l.Shutdown(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	opts.Logger = l.NewLogEvent()
+	w := httptest.NewRecorder()
+	Middleware(opts)(h).ServeHTTP(w, r)
+
+	return w, d
+}
+
+func TestRequestIDFromHeader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+
+	var tags map[string]string
+	w, _ := serve(t, Options{}, r, func(w http.ResponseWriter, r *http.Request) {
+		tags = logger.TagsFromContext(r.Context())
+	})
+
+	if tags["requestId"] != "req-1" {
+		t.Errorf("handler got requestId %q, want req-1", tags["requestId"])
+	}
+
+	if got := w.Header().Get("X-Request-Id"); got != "req-1" {
+		t.Errorf("response X-Request-Id %q, want req-1", got)
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	var tags map[string]string
+	w, _ := serve(t, Options{}, httptest.NewRequest(http.MethodGet, "/items", nil), func(w http.ResponseWriter, r *http.Request) {
+		tags = logger.TagsFromContext(r.Context())
+	})
+
+	id := tags["requestId"]
+	if len(id) != 36 {
+		t.Errorf("generated request id %q is not a UUID", id)
+	}
+
+	if got := w.Header().Get("X-Request-Id"); got != id {
+		t.Errorf("response X-Request-Id %q, want %q", got, id)
+	}
+}
+
+func TestTagsAndUserInContext(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	r.Header.Set("X-Source", "billing")
+
+	opts := Options{
+		Tags: func(r *http.Request) map[string]string {
+			return map[string]string{"path": r.URL.Path}
+		},
+		User: func(r *http.Request) *logger.UserForLog {
+			return &logger.UserForLog{ID: "u1"}
+		},
+		DisableAccessLog: true,
+	}
+
+	_, d := serve(t, opts, r, func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Log(r.Context(), "in handler")
+	})
+
+	msgs := d.byType("LOG")
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+
+	m := msgs[0]
+	if m.Tags["source"] != "billing" || m.Tags["path"] != "/items" || len(m.Tags["requestId"]) != 36 {
+		t.Errorf("unexpected tags %v", m.Tags)
+	}
+
+	if m.User == nil || m.User.ID != "u1" {
+		t.Errorf("got user %+v, want u1", m.User)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	_, d := serve(t, Options{}, httptest.NewRequest(http.MethodPost, "/items", nil), func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	msgs := d.byType("LOG")
+	if len(msgs) != 1 {
+		t.Fatalf("got %d access log messages, want 1", len(msgs))
+	}
+
+	if msgs[0].Data != "POST /items 201" {
+		t.Errorf("got %v, want POST /items 201", msgs[0].Data)
+	}
+
+	fields := msgs[0].Fields.Map()
+	if fields["status"] != int64(201) || fields["bytes"] != int64(5) || fields["method"] != "POST" || fields["path"] != "/items" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	latency, _ := fields["latency"].(string)
+	if d, _ := time.ParseDuration(latency); d < time.Millisecond {
+		t.Errorf("latency %v, want at least 1ms", fields["latency"])
+	}
+}
+
+func TestAccessLogServerErrorIsError(t *testing.T) {
+	_, d := serve(t, Options{}, httptest.NewRequest(http.MethodGet, "/items", nil), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if msgs := d.byType("ERROR"); len(msgs) != 1 || msgs[0].Data != "GET /items 503" {
+		t.Errorf("got ERROR messages %v, want GET /items 503", msgs)
+	}
+
+	if msgs := d.byType("LOG"); len(msgs) != 0 {
+		t.Errorf("got %d LOG messages, want 0", len(msgs))
+	}
+}
+
+//go:noinline
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestPanicBecomesAlert(t *testing.T) {
+	w, d := serve(t, Options{}, httptest.NewRequest(http.MethodGet, "/items", nil), panickingHandler)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", w.Code)
+	}
+
+	alerts := d.byType("ALERT")
+	if len(alerts) != 1 {
+		t.Fatalf("got %d ALERT messages, want 1", len(alerts))
+	}
+
+	m := alerts[0]
+	if s, _ := m.Data.(string); !strings.Contains(s, "boom") {
+		t.Errorf("ALERT does not mention the panic: %v", m.Data)
+	}
+
+	if m.Request == nil || m.Request.URL.Path != "/items" {
+		t.Errorf("ALERT has request %v, want /items", m.Request)
+	}
+
+	if len(m.PCs) == 0 {
+		t.Fatal("ALERT has no stack")
+	}
+
+	if f, _ := runtime.CallersFrames(m.PCs).Next(); !strings.HasSuffix(f.Function, ".panickingHandler") {
+		t.Errorf("ALERT stack starts at %s, want the panicking handler", f.Function)
+	}
+
+	if msgs := d.byType("ERROR"); len(msgs) != 1 || msgs[0].Data != "GET /items 500" {
+		t.Errorf("got access log %v, want GET /items 500", msgs)
+	}
+}
+
+func TestErrAbortHandlerPanicsAgain(t *testing.T) {
+	var rec interface{}
+	var d *memDriver
+	func() {
+		defer func() {
+			rec = recover()
+		}()
+
+		_, d = serve(t, Options{}, httptest.NewRequest(http.MethodGet, "/items", nil), func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+	}()
+
+	if rec != http.ErrAbortHandler {
+		t.Errorf("recovered %v, want http.ErrAbortHandler", rec)
+	}
+
+	if d != nil {
+		t.Error("serve returned after http.ErrAbortHandler")
+	}
+}
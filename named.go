@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Named возвращает событие дочернего логгера с именем name, которое попадает в поле logger сообщения.
+// Уровень для имени берется из LoggerConfig.LevelOverrides по самому длинному совпадающему префиксу
+func (l *Logger) Named(name string) *LogEvent {
+	return l.NewLogEvent().Named(name)
+}
+
+// Named возвращает копию события с именем, дополненным через точку: "billing" -> "billing.invoices"
+func (e *LogEvent) Named(name string) *LogEvent {
+	child := e.clone()
+	if child.name != "" && name != "" {
+		child.name += "." + name
+	} else if name != "" {
+		child.name = name
+	}
+	child.level, child.hasLevel = e.l.levelOverride(child.name)
+
+	return child
+}
+
+// GetName имя дочернего логгера
+func (e *LogEvent) GetName() string {
+	return e.name
+}
+
+// levelOverride ищет уровень для имени по самому длинному префиксу из LevelOverrides.
+// Префикс совпадает целиком с именем или с его частью до точки: "billing" подходит для "billing.invoices",
+// но не для "billingv2"
+func (l *Logger) levelOverride(name string) (int, bool) {
+	if l == nil || name == "" {
+		return 0, false
+	}
+
+	best := -1
+	level := 0
+	for prefix, lvl := range l.Config.LevelOverrides {
+		if len(prefix) <= best {
+			continue
+		}
+
+		if name == prefix || strings.HasPrefix(name, prefix+".") {
+			best = len(prefix)
+			level = lvl
+		}
+	}
+
+	return level, best >= 0
+}
+
+// ParseLevelOverrides разбирает строку вида "billing=DEBUG, billing.invoices=TRACE" для LoggerConfig.LevelOverrides
+func ParseLevelOverrides(s string) (map[string]int, error) {
+	res := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid level override %q", part)
+		}
+
+		level, err := ParseLevel(kv[1])
+		if err != nil {
+			return nil, err
+		}
+
+		res[strings.TrimSpace(kv[0])] = level
+	}
+
+	return res, nil
+}
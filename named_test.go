@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestLevelOverrideLongestPrefix(t *testing.T) {
+	l := &Logger{Config: LoggerConfig{LevelOverrides: map[string]int{
+		"billing":          DEBUG,
+		"billing.invoices": TRACE,
+		"api":              ERROR,
+	}}}
+
+	cases := []struct {
+		name  string
+		level int
+		ok    bool
+	}{
+		{"billing", DEBUG, true},
+		{"billing.payments", DEBUG, true},
+		{"billing.invoices", TRACE, true},
+		{"billing.invoices.pdf", TRACE, true},
+		{"billingv2", 0, false},
+		{"bill", 0, false},
+		{"api.v1", ERROR, true},
+		{"", 0, false},
+	}
+
+	for _, c := range cases {
+		level, ok := l.levelOverride(c.name)
+		if level != c.level || ok != c.ok {
+			t.Errorf("levelOverride(%q) = %d, %v, want %d, %v", c.name, level, ok, c.level, c.ok)
+		}
+	}
+}
+
+func TestNamedUsesOverride(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{
+		Level:          ERROR,
+		Output:         []LogDriver{d},
+		LevelOverrides: map[string]int{"billing": DEBUG},
+	})
+
+	ctx := context.Background()
+	l.Named("billing").Named("invoices").Debug(ctx, "invoice")
+	l.Named("billingv2").Debug(ctx, "v2")
+	l.NewLogEvent().Debug(ctx, "root")
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if got := messageData(msgs); !reflect.DeepEqual(got, []interface{}{"invoice"}) {
+		t.Fatalf("got %v, want [invoice]", got)
+	}
+
+	if msgs[0].Logger != "billing.invoices" {
+		t.Errorf("logger name %q, want billing.invoices", msgs[0].Logger)
+	}
+}
+
+func TestParseLevelOverrides(t *testing.T) {
+	got, err := ParseLevelOverrides(" billing=debug, billing.invoices=TRACE ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"billing": DEBUG, "billing.invoices": TRACE}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, s := range []string{"billing", "=DEBUG", "billing=LOUD"} {
+		if _, err := ParseLevelOverrides(s); err == nil {
+			t.Errorf("ParseLevelOverrides(%q) returned no error", s)
+		}
+	}
+}
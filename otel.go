@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const spanEventName = "log"
+
+// setSpanContext добавляет в сообщение идентификаторы активного спана OpenTelemetry из контекста сообщения
+func setSpanContext(m *Message) {
+	if m.Ctx == nil {
+		return
+	}
+
+	sc := trace.SpanContextFromContext(m.Ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	m.TraceID = sc.TraceID().String()
+	m.SpanID = sc.SpanID().String()
+	m.TraceFlags = sc.TraceFlags().String()
+}
+
+// addSpanEvent записывает сообщение событием в активный спан, вызывается в месте логирования,
+// пока спан еще не завершен
+func (e *LogEvent) addSpanEvent(bm blankMsg) {
+	span := trace.SpanFromContext(bm.ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("log.severity", LevelName(bm.level)),
+		attribute.String("log.message", DataText(bm.data)),
+	}
+	if e.name != "" {
+		attrs = append(attrs, attribute.String("log.logger", e.name))
+	}
+
+	span.AddEvent(spanEventName, trace.WithAttributes(attrs...), trace.WithTimestamp(bm.time))
+}
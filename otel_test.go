@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var testSpanContext = trace.NewSpanContext(trace.SpanContextConfig{
+	TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+	SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	TraceFlags: trace.FlagsSampled,
+})
+
+// recordingSpan спан, который запоминает события, остальные методы trace.Span не вызываются
+type recordingSpan struct {
+	trace.Span
+
+	mu     sync.Mutex
+	events []trace.EventConfig
+	names  []string
+}
+
+func (s *recordingSpan) IsRecording() bool {
+	return true
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext {
+	return testSpanContext
+}
+
+func (s *recordingSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.names = append(s.names, name)
+	s.events = append(s.events, trace.NewEventConfig(options...))
+}
+
+func TestSpanContextInMessage(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+
+	ev := l.NewLogEvent()
+	ev.Log(trace.ContextWithSpanContext(context.Background(), testSpanContext), "in span")
+	ev.Log(context.Background(), "no span")
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+
+	m := msgs[0]
+	if m.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || m.SpanID != "00f067aa0ba902b7" || m.TraceFlags != "01" {
+		t.Errorf("got trace_id %q, span_id %q, trace_flags %q", m.TraceID, m.SpanID, m.TraceFlags)
+	}
+
+	if m := msgs[1]; m.TraceID != "" || m.SpanID != "" || m.TraceFlags != "" {
+		t.Errorf("message without span got trace_id %q, span_id %q, trace_flags %q", m.TraceID, m.SpanID, m.TraceFlags)
+	}
+}
+
+func TestSpanEvents(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}, SpanEvents: true})
+
+	span := &recordingSpan{}
+	ctx := trace.ContextWithSpan(context.Background(), span)
+	l.Named("billing").Error(ctx, "boom")
+	// неактивный спан событий не получает
+	l.NewLogEvent().Log(trace.ContextWithSpanContext(context.Background(), testSpanContext), "not recording")
+	shutdown(t, l)
+
+	if len(span.events) != 1 || span.names[0] != "log" {
+		t.Fatalf("got events %v, want one log event", span.names)
+	}
+
+	want := map[attribute.Key]string{
+		"log.severity": "ERROR",
+		"log.message":  "boom",
+		"log.logger":   "billing",
+	}
+	got := make(map[attribute.Key]string)
+	for _, kv := range span.events[0].Attributes() {
+		got[kv.Key] = kv.Value.AsString()
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("attribute %s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	msgs := d.messages()
+	if ts := span.events[0].Timestamp(); !ts.Equal(msgs[0].Timestamp) {
+		t.Errorf("event timestamp %s, want the message timestamp %s", ts, msgs[0].Timestamp)
+	}
+
+	if msgs[0].TraceID != testSpanContext.TraceID().String() {
+		t.Errorf("message trace_id %q, want the span's", msgs[0].TraceID)
+	}
+}
+
+func TestSpanEventsDisabled(t *testing.T) {
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{&memDriver{}}})
+
+	span := &recordingSpan{}
+	l.NewLogEvent().Error(trace.ContextWithSpan(context.Background(), span), "boom")
+	shutdown(t, l)
+
+	if len(span.events) != 0 {
+		t.Errorf("got %d span events without SpanEvents", len(span.events))
+	}
+}
@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy поведение при переполнении очереди логгера или драйвера
+type OverflowPolicy int
+
+const (
+	// OverflowDefault для очереди логгера Block, для очередей драйверов BlockWithTimeout,
+	// чтобы переполненная очередь одного драйвера не задерживала остальные
+	OverflowDefault OverflowPolicy = iota
+	// Block ждать, пока в очереди освободится место
+	Block
+	// BlockWithTimeout ждать не дольше OverflowTimeout, потом отбросить сообщение
+	BlockWithTimeout
+	// DropNewest отбросить новое сообщение
+	DropNewest
+	// DropOldest отбросить самое старое сообщение в очереди и поставить новое
+	DropOldest
+	// DropBelowLevel отбросить сообщение, если его уровень менее важен, чем LoggerConfig.OverflowLevel,
+	// остальные ждут как при Block
+	DropBelowLevel
+)
+
+const (
+	defaultOverflowTimeout = 100 * time.Millisecond
+	// defaultDriverBuffer размер очереди драйвера, если не заданы DriverConfig.Buffer и LoggerConfig.Buffer
+	defaultDriverBuffer = 1000
+)
+
+// drops политика отбрасывает сообщения, как только очередь занята. Без буфера очередь занята,
+// пока получатель обрабатывает предыдущее сообщение, поэтому такие политики требуют Buffer > 0
+func (p OverflowPolicy) drops() bool {
+	return p == DropNewest || p == DropOldest || p == DropBelowLevel
+}
+
+// checkOverflow проверяет, что у очереди логгера с отбрасывающей политикой есть буфер,
+// у очередей драйверов он есть всегда
+func checkOverflow(config LoggerConfig) error {
+	if !config.Overflow.drops() {
+		return nil
+	}
+
+	if config.Buffer <= 0 {
+		return errors.New("logger: DropNewest, DropOldest and DropBelowLevel need LoggerConfig.Buffer > 0")
+	}
+
+	return nil
+}
+
+// enqueue ставит сообщение в очередь логгера согласно OverflowPolicy, после Shutdown сообщения игнорируются
+func (l *Logger) enqueue(msg blankMsg) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return
+	}
+
+	atomic.AddInt64(&l.pending, 1)
+	if !l.push(msg) {
+		atomic.AddInt64(&l.pending, -1)
+		l.dropped.inc(msg.level)
+	}
+}
+
+func (l *Logger) push(msg blankMsg) bool {
+	select {
+	case l.Msg <- msg:
+		return true
+	default:
+	}
+
+	switch l.Config.Overflow {
+	case BlockWithTimeout:
+		t := time.NewTimer(l.overflowTimeout())
+		defer t.Stop()
+
+		select {
+		case l.Msg <- msg:
+			return true
+		case <-t.C:
+			return false
+		case <-l.closing:
+			return false
+		}
+	case DropNewest:
+		return false
+	case DropOldest:
+		for {
+			select {
+			case old := <-l.Msg:
+				atomic.AddInt64(&l.pending, -1)
+				l.dropped.inc(old.level)
+			default:
+			}
+
+			select {
+			case l.Msg <- msg:
+				return true
+			default:
+			}
+		}
+	case DropBelowLevel:
+		if msg.level > l.Config.OverflowLevel {
+			return false
+		}
+	}
+
+	select {
+	case l.Msg <- msg:
+		return true
+	case <-l.closing:
+		return false
+	}
+}
+
+func (q *driverQueue) enqueue(msg messages) {
+	atomic.AddInt64(&q.pending, 1)
+	if !q.push(msg) {
+		atomic.AddInt64(&q.pending, -1)
+		q.dropped.inc(msg.Level)
+	}
+}
+
+func (q *driverQueue) push(msg messages) bool {
+	select {
+	case q.in <- msg:
+		return true
+	default:
+	}
+
+	switch q.overflow {
+	case BlockWithTimeout:
+		t := time.NewTimer(q.overflowTimeout)
+		defer t.Stop()
+
+		select {
+		case q.in <- msg:
+			return true
+		case <-t.C:
+			return false
+		case <-q.abort:
+			return false
+		}
+	case DropNewest:
+		return false
+	case DropOldest:
+		for {
+			select {
+			case old := <-q.in:
+				atomic.AddInt64(&q.pending, -1)
+				q.dropped.inc(old.Level)
+			default:
+			}
+
+			select {
+			case q.in <- msg:
+				return true
+			default:
+			}
+		}
+	case DropBelowLevel:
+		if msg.Level > q.overflowLevel {
+			return false
+		}
+	}
+
+	select {
+	case q.in <- msg:
+		return true
+	case <-q.abort:
+		return false
+	}
+}
+
+// driverOverflow политика очереди драйвера: своя из DriverConfig, иначе общая из LoggerConfig,
+// иначе BlockWithTimeout
+func (l *Logger) driverOverflow(dc DriverConfig) (OverflowPolicy, time.Duration, int) {
+	if dc.Overflow != OverflowDefault {
+		timeout := dc.OverflowTimeout
+		if timeout <= 0 {
+			timeout = defaultOverflowTimeout
+		}
+
+		return dc.Overflow, timeout, dc.OverflowLevel
+	}
+
+	if l.Config.Overflow != OverflowDefault {
+		return l.Config.Overflow, l.overflowTimeout(), l.Config.OverflowLevel
+	}
+
+	return BlockWithTimeout, l.overflowTimeout(), l.Config.OverflowLevel
+}
+
+func (l *Logger) overflowTimeout() time.Duration {
+	if l.Config.OverflowTimeout <= 0 {
+		return defaultOverflowTimeout
+	}
+
+	return l.Config.OverflowTimeout
+}
@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockedLogger логгер с одним драйвером, который завис на первом сообщении
+func blockedLogger(t *testing.T, config LoggerConfig) (*Logger, *memDriver) {
+	t.Helper()
+
+	d := &memDriver{block: make(chan struct{}), entered: make(chan struct{}, 1)}
+	config.Output = []LogDriver{d}
+	l := newTestLogger(t, config)
+
+	l.NewLogEvent().Error(context.Background(), 0)
+	select {
+	case <-d.entered:
+	case <-time.After(time.Second):
+		t.Fatal("driver did not receive the first message")
+	}
+
+	return l, d
+}
+
+// waitDispatched ждет, пока диспетчер разложит все сообщения из очереди логгера по очередям драйверов
+func waitDispatched(t *testing.T, l *Logger) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&l.pending) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("dispatcher is stuck")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func totalDropped(s Stats, level string) uint64 {
+	return s.Dropped[level] + s.DriverDropped[level]
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	l, d := blockedLogger(t, LoggerConfig{Buffer: 2, Overflow: DropNewest})
+	ev := l.NewLogEvent()
+
+	for i := 1; i < 10; i++ {
+		ev.Error(context.Background(), i)
+	}
+	waitDispatched(t, l)
+	close(d.block)
+	shutdown(t, l)
+
+	if got, want := messageData(d.messages()), []interface{}{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+
+	if got := totalDropped(l.Stats(), "ERROR"); got != 7 {
+		t.Errorf("dropped %d, want 7", got)
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	l, d := blockedLogger(t, LoggerConfig{Buffer: 2, Overflow: DropOldest})
+	ev := l.NewLogEvent()
+
+	for i := 1; i < 10; i++ {
+		ev.Error(context.Background(), i)
+	}
+	waitDispatched(t, l)
+	close(d.block)
+	shutdown(t, l)
+
+	if got, want := messageData(d.messages()), []interface{}{0, 8, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+
+	if got := totalDropped(l.Stats(), "ERROR"); got != 7 {
+		t.Errorf("dropped %d, want 7", got)
+	}
+}
+
+func TestOverflowDropBelowLevel(t *testing.T) {
+	l, d := blockedLogger(t, LoggerConfig{Buffer: 2, Overflow: DropBelowLevel, OverflowLevel: ERROR})
+	ev := l.NewLogEvent()
+
+	for i := 1; i <= 4; i++ {
+		ev.Log(context.Background(), i)
+	}
+	waitDispatched(t, l)
+
+	done := make(chan struct{})
+	go func() {
+		ev.Error(context.Background(), 5)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(d.block)
+	<-done
+	shutdown(t, l)
+
+	if got, want := messageData(d.messages()), []interface{}{0, 1, 2, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+
+	s := l.Stats()
+	if got := totalDropped(s, "LOG"); got != 2 {
+		t.Errorf("dropped LOG %d, want 2", got)
+	}
+
+	if got := totalDropped(s, "ERROR"); got != 0 {
+		t.Errorf("dropped ERROR %d, want 0", got)
+	}
+}
+
+func TestOverflowBlockWithTimeout(t *testing.T) {
+	l, d := blockedLogger(t, LoggerConfig{Buffer: 1, Overflow: BlockWithTimeout, OverflowTimeout: 10 * time.Millisecond})
+	ev := l.NewLogEvent()
+
+	start := time.Now()
+	for i := 1; i < 10; i++ {
+		ev.Error(context.Background(), i)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("logging took %s with a blocked driver", elapsed)
+	}
+
+	close(d.block)
+	shutdown(t, l)
+
+	delivered := uint64(len(d.messages()))
+	dropped := totalDropped(l.Stats(), "ERROR")
+	if dropped == 0 || delivered+dropped != 10 {
+		t.Errorf("delivered %d, dropped %d, want some dropped and 10 in total", delivered, dropped)
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	l, d := blockedLogger(t, LoggerConfig{Buffer: 1, Overflow: Block})
+	ev := l.NewLogEvent()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 1; i < 10; i++ {
+			ev.Error(context.Background(), i)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("logging did not block on a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(d.block)
+	<-done
+	shutdown(t, l)
+
+	if n := len(d.messages()); n != 10 {
+		t.Errorf("delivered %d, want 10", n)
+	}
+
+	if got := totalDropped(l.Stats(), "ERROR"); got != 0 {
+		t.Errorf("dropped %d, want 0", got)
+	}
+}
+
+func TestOverflowDropPolicyNeedsBuffer(t *testing.T) {
+	for _, policy := range []OverflowPolicy{DropNewest, DropOldest, DropBelowLevel} {
+		if _, err := GetLogger(LoggerConfig{Overflow: policy, Output: []LogDriver{&memDriver{}}}); err == nil {
+			t.Errorf("policy %d: GetLogger accepted Buffer 0", policy)
+		}
+	}
+
+	l, err := GetLogger(LoggerConfig{Overflow: BlockWithTimeout, Output: []LogDriver{&memDriver{}}})
+	if err != nil {
+		t.Fatalf("BlockWithTimeout without buffer: %v", err)
+	}
+	shutdown(t, l)
+}
@@ -0,0 +1,75 @@
+package logger
+
+import "context"
+
+// Processor обрабатывает готовое сообщение до отправки в драйверы: дополняет, фильтрует, маскирует, переименовывает.
+// Вызывается один раз на сообщение в фоновом воркере, в порядке LoggerConfig.Processors.
+// false отбрасывает сообщение, следующие процессоры и драйверы его не получат
+type Processor interface {
+	Process(ctx context.Context, msg *Message) (keep bool)
+}
+
+// ProcessorFunc функция как Processor
+type ProcessorFunc func(ctx context.Context, msg *Message) bool
+
+func (f ProcessorFunc) Process(ctx context.Context, msg *Message) bool {
+	return f(ctx, msg)
+}
+
+// process прогоняет сообщение через процессоры. Паника процессора уходит в ErrorHandler как *ProcessorError,
+// сообщение передается дальше без изменений этого процессора
+func (l *Logger) process(ctx context.Context, m *messages) bool {
+	for _, p := range l.Config.Processors {
+		if !l.runProcessor(ctx, p, m) {
+			l.filtered.inc(m.Level)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (l *Logger) runProcessor(ctx context.Context, p Processor, m *messages) (keep bool) {
+	// процессор получает копию Tags, Extra, Fields и User, чтобы после паники вернуть сообщение как было
+	msg := m.Msg
+	m.Msg = copyMessage(msg)
+	defer func() {
+		if r := recover(); r != nil {
+			m.Msg = msg
+			keep = true
+			l.Config.ErrorHandler(msg, &ProcessorError{Processor: p, err: &PanicError{Value: r}})
+		}
+	}()
+
+	return p.Process(ctx, &m.Msg)
+}
+
+// copyMessage копия сообщения с собственными Tags, Extra, Fields и User
+func copyMessage(msg Message) Message {
+	if msg.Tags != nil {
+		tags := make(map[string]string, len(msg.Tags))
+		for k, v := range msg.Tags {
+			tags[k] = v
+		}
+		msg.Tags = tags
+	}
+
+	if msg.Extra != nil {
+		extra := make(map[string]interface{}, len(msg.Extra))
+		for k, v := range msg.Extra {
+			extra[k] = v
+		}
+		msg.Extra = extra
+	}
+
+	if msg.Fields != nil {
+		msg.Fields = append(Fields(nil), msg.Fields...)
+	}
+
+	if msg.User != nil {
+		user := *msg.User
+		msg.User = &user
+	}
+
+	return msg
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestProcessorsRunInOrder(t *testing.T) {
+	d := &memDriver{}
+	var order []string
+	l := newTestLogger(t, LoggerConfig{
+		Output: []LogDriver{d},
+		Processors: []Processor{
+			ProcessorFunc(func(ctx context.Context, msg *Message) bool {
+				order = append(order, "first")
+				msg.Data = msg.Data.(string) + " first"
+				return true
+			}),
+			ProcessorFunc(func(ctx context.Context, msg *Message) bool {
+				order = append(order, "second")
+				msg.Data = msg.Data.(string) + " second"
+				return true
+			}),
+		},
+	})
+
+	l.NewLogEvent().Log(context.Background(), "msg")
+	shutdown(t, l)
+
+	if got := messageData(d.messages()); !reflect.DeepEqual(got, []interface{}{"msg first second"}) {
+		t.Errorf("got %v", got)
+	}
+
+	if !reflect.DeepEqual(order, []string{"first", "second"}) {
+		t.Errorf("processors ran in order %v", order)
+	}
+}
+
+func TestProcessorFilters(t *testing.T) {
+	d := &memDriver{}
+	var after int
+	l := newTestLogger(t, LoggerConfig{
+		Output: []LogDriver{d},
+		Processors: []Processor{
+			ProcessorFunc(func(ctx context.Context, msg *Message) bool {
+				return msg.MessageType != "DEBUG"
+			}),
+			ProcessorFunc(func(ctx context.Context, msg *Message) bool {
+				after++
+				return true
+			}),
+		},
+	})
+
+	ev := l.NewLogEvent()
+	ev.Debug(context.Background(), "dropped")
+	ev.Debug(context.Background(), "dropped")
+	ev.Log(context.Background(), "kept")
+	shutdown(t, l)
+
+	if got := messageData(d.messages()); !reflect.DeepEqual(got, []interface{}{"kept"}) {
+		t.Errorf("got %v, want [kept]", got)
+	}
+
+	if after != 1 {
+		t.Errorf("next processor ran %d times, want 1", after)
+	}
+
+	if got := l.Stats().Filtered; !reflect.DeepEqual(got, map[string]uint64{"DEBUG": 2}) {
+		t.Errorf("Stats().Filtered = %v, want DEBUG: 2", got)
+	}
+}
+
+func TestProcessorPanicRollsBack(t *testing.T) {
+	d := &memDriver{}
+	var mu sync.Mutex
+	var errs []error
+	broken := ProcessorFunc(func(ctx context.Context, msg *Message) bool {
+		msg.Tags["secret"] = "changed"
+		msg.Extra["added"] = true
+		msg.User.ID = "changed"
+		msg.Fields[0] = String("k", "changed")
+		panic("broken processor")
+	})
+
+	l := newTestLogger(t, LoggerConfig{
+		Output:     []LogDriver{d},
+		Processors: []Processor{broken},
+		ErrorHandler: func(msg Message, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+
+	ctx := ContextWithUser(context.Background(), &UserForLog{ID: "u1"})
+	l.NewLogEvent().With(Tag("secret", "original"), Extra("e", 1), String("k", "v")).Log(ctx, "msg")
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+
+	m := msgs[0]
+	if m.Tags["secret"] != "original" || m.Extra["added"] != nil || m.User.ID != "u1" || m.Fields[0].Value() != "v" {
+		t.Errorf("changes of the panicking processor survived: tags %v, extra %v, user %+v, fields %v",
+			m.Tags, m.Extra, m.User, m.Fields)
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("ErrorHandler called %d times, want 1", len(errs))
+	}
+
+	var pe *ProcessorError
+	var panicErr *PanicError
+	if !errors.As(errs[0], &pe) || !errors.As(errs[0], &panicErr) || panicErr.Value != "broken processor" {
+		t.Errorf("ErrorHandler got %v, want *ProcessorError wrapping *PanicError", errs[0])
+	}
+}
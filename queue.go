@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultMaxRetryBackoff = 5 * time.Second
+)
+
+// driverQueue очередь и воркеры отдельного драйвера, чтобы медленный драйвер не тормозил остальные
+type driverQueue struct {
+	// pending сообщения в очереди и в обработке воркерами
+	pending int64
+
+	driver  LogDriver
+	in      chan messages
+	workers int
+	wg      sync.WaitGroup
+
+	retries         int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+	fallback        LogDriver
+	errorHandler    ErrorHandler
+	abort           chan struct{}
+
+	overflow        OverflowPolicy
+	overflowTimeout time.Duration
+	overflowLevel   int
+	dropped         *levelCounter
+}
+
+func newDriverQueue(dc DriverConfig, l *Logger) *driverQueue {
+	buffer := dc.Buffer
+	if buffer <= 0 {
+		buffer = l.Config.Buffer
+	}
+	if buffer <= 0 {
+		buffer = defaultDriverBuffer
+	}
+
+	workers := dc.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	retryBackoff := dc.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = defaultRetryBackoff
+	}
+
+	maxRetryBackoff := dc.MaxRetryBackoff
+	if maxRetryBackoff <= 0 {
+		maxRetryBackoff = defaultMaxRetryBackoff
+	}
+
+	overflow, overflowTimeout, overflowLevel := l.driverOverflow(dc)
+
+	return &driverQueue{
+		driver:          dc.Driver,
+		in:              make(chan messages, buffer),
+		workers:         workers,
+		retries:         dc.Retries,
+		retryBackoff:    retryBackoff,
+		maxRetryBackoff: maxRetryBackoff,
+		fallback:        dc.Fallback,
+		errorHandler:    l.Config.ErrorHandler,
+		abort:           l.abort,
+		overflow:        overflow,
+		overflowTimeout: overflowTimeout,
+		overflowLevel:   overflowLevel,
+		dropped:         l.driverDropped,
+	}
+}
+
+func (q *driverQueue) start() {
+	q.wg.Add(q.workers)
+	for i := 0; i < q.workers; i++ {
+		go func() {
+			defer q.wg.Done()
+			q.work()
+		}()
+	}
+}
+
+func (q *driverQueue) work() {
+	for msg := range q.in {
+		q.deliver(msg.Msg)
+		atomic.AddInt64(&q.pending, -1)
+	}
+}
+
+// deliver отправляет сообщение в драйвер с повторами, при неудаче в fallback драйвер
+func (q *driverQueue) deliver(msg Message) {
+	backoff := q.retryBackoff
+	attempts := 0
+
+	for {
+		attempts++
+		err := putMsg(q.driver, msg)
+		if err == nil {
+			return
+		}
+
+		if attempts > q.retries {
+			q.errorHandler(msg, &DriverError{Driver: q.driver, Attempts: attempts, err: err})
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-q.abort:
+			q.errorHandler(msg, &DriverError{Driver: q.driver, Attempts: attempts, err: err})
+			return
+		}
+
+		backoff *= 2
+		if backoff > q.maxRetryBackoff {
+			backoff = q.maxRetryBackoff
+		}
+	}
+
+	if q.fallback == nil {
+		return
+	}
+
+	err := putMsg(q.fallback, msg)
+	if err != nil {
+		q.errorHandler(msg, &DriverError{Driver: q.fallback, Attempts: 1, err: err})
+	}
+}
+
+// asyncErrorHandler обработчик для ErrorReporter: сообщение, которое драйвер не смог доставить после PutMsg,
+// уходит в fallback, ошибки в LoggerConfig.ErrorHandler. Повторы драйвер делает сам, поэтому Attempts 1
+func (l *Logger) asyncErrorHandler(driver, fallback LogDriver) ErrorHandler {
+	return func(msg Message, err error) {
+		l.Config.ErrorHandler(msg, &DriverError{Driver: driver, Attempts: 1, err: err})
+
+		if fallback == nil {
+			return
+		}
+
+		if err := putMsg(fallback, msg); err != nil {
+			l.Config.ErrorHandler(msg, &DriverError{Driver: fallback, Attempts: 1, err: err})
+		}
+	}
+}
+
+// putMsg вызывает драйвер, превращая панику в ошибку
+func putMsg(driver LogDriver, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in PutMsg: %v", r)
+		}
+	}()
+
+	return driver.PutMsg(msg)
+}
+
+// stop закрывает очередь и ждет, пока воркеры обработают оставшиеся сообщения
+func (q *driverQueue) stop() {
+	close(q.in)
+	q.wg.Wait()
+}
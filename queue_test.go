@@ -0,0 +1,291 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSlowDriverDoesNotBlockOthers(t *testing.T) {
+	slow := &memDriver{block: make(chan struct{})}
+	fast := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Buffer: 100, Output: []LogDriver{slow, fast}})
+	ev := l.NewLogEvent()
+
+	for i := 0; i < 50; i++ {
+		ev.Log(context.Background(), i)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(fast.messages()) < 50 {
+		if time.Now().After(deadline) {
+			t.Fatalf("fast driver got %d of 50 messages while the slow one is blocked", len(fast.messages()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := len(slow.messages()); n != 0 {
+		t.Errorf("blocked driver stored %d messages", n)
+	}
+
+	close(slow.block)
+	shutdown(t, l)
+
+	if n := len(slow.messages()); n != 50 {
+		t.Errorf("slow driver got %d messages after unblock, want 50", n)
+	}
+}
+
+func TestDriverWorkers(t *testing.T) {
+	d := &memDriver{delay: 20 * time.Millisecond}
+	l := newTestLogger(t, LoggerConfig{Drivers: []DriverConfig{{Driver: d, Buffer: 10, Workers: 5}}})
+	ev := l.NewLogEvent()
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		ev.Log(context.Background(), i)
+	}
+	shutdown(t, l)
+
+	if n := len(d.messages()); n != 10 {
+		t.Fatalf("got %d messages, want 10", n)
+	}
+
+	// один воркер потратил бы 200ms
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("5 workers took %s for 10 messages of 20ms", elapsed)
+	}
+}
+
+// flakyDriver возвращает ошибку на первых fails вызовах
+type flakyDriver struct {
+	memDriver
+	fails int32
+}
+
+func (d *flakyDriver) PutMsg(msg Message) error {
+	if atomic.AddInt32(&d.fails, -1) >= 0 {
+		return errors.New("flaky")
+	}
+
+	return d.memDriver.PutMsg(msg)
+}
+
+type panicDriver struct{}
+
+func (panicDriver) Init() error {
+	return nil
+}
+
+func (panicDriver) PutMsg(msg Message) error {
+	panic("broken driver")
+}
+
+// errorRecorder ErrorHandler, который запоминает ошибки
+type errorRecorder struct {
+	mu   sync.Mutex
+	errs []*DriverError
+}
+
+func (r *errorRecorder) handle(msg Message, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	de, _ := err.(*DriverError)
+	r.errs = append(r.errs, de)
+}
+
+func (r *errorRecorder) errors() []*DriverError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*DriverError(nil), r.errs...)
+}
+
+func TestDriverRetries(t *testing.T) {
+	d := &flakyDriver{fails: 2}
+	rec := &errorRecorder{}
+	l := newTestLogger(t, LoggerConfig{
+		Drivers:      []DriverConfig{{Driver: d, Retries: 2, RetryBackoff: time.Millisecond}},
+		ErrorHandler: rec.handle,
+	})
+
+	l.NewLogEvent().Log(context.Background(), "msg")
+	shutdown(t, l)
+
+	if n := len(d.messages()); n != 1 {
+		t.Errorf("got %d messages after retries, want 1", n)
+	}
+
+	if errs := rec.errors(); len(errs) != 0 {
+		t.Errorf("ErrorHandler called for a message delivered by retry: %v", errs)
+	}
+}
+
+func TestDriverFallback(t *testing.T) {
+	failure := errors.New("down")
+	primary := &memDriver{err: failure}
+	fallback := &memDriver{}
+	rec := &errorRecorder{}
+	l := newTestLogger(t, LoggerConfig{
+		Drivers: []DriverConfig{{
+			Driver:       primary,
+			Retries:      2,
+			RetryBackoff: time.Millisecond,
+			Fallback:     fallback,
+		}},
+		ErrorHandler: rec.handle,
+	})
+
+	l.NewLogEvent().Log(context.Background(), "msg")
+	shutdown(t, l)
+
+	if n := primary.callCount(); n != 3 {
+		t.Errorf("primary called %d times, want 3", n)
+	}
+
+	if got := messageData(fallback.messages()); !reflect.DeepEqual(got, []interface{}{"msg"}) {
+		t.Errorf("fallback got %v", got)
+	}
+
+	errs := rec.errors()
+	if len(errs) != 1 || errs[0] == nil {
+		t.Fatalf("want one *DriverError, got %v", errs)
+	}
+
+	if errs[0].Driver != primary || errs[0].Attempts != 3 || !errors.Is(errs[0], failure) {
+		t.Errorf("unexpected error %v", errs[0])
+	}
+}
+
+func TestDriverFallbackFails(t *testing.T) {
+	rec := &errorRecorder{}
+	fallback := &memDriver{err: errors.New("fallback down")}
+	l := newTestLogger(t, LoggerConfig{
+		Drivers:      []DriverConfig{{Driver: panicDriver{}, Fallback: fallback}},
+		ErrorHandler: rec.handle,
+	})
+
+	l.NewLogEvent().Log(context.Background(), "msg")
+	shutdown(t, l)
+
+	errs := rec.errors()
+	if len(errs) != 2 || errs[0] == nil || errs[1] == nil {
+		t.Fatalf("want two *DriverError, got %v", errs)
+	}
+
+	if _, ok := errs[0].Driver.(panicDriver); !ok || errs[0].Attempts != 1 {
+		t.Errorf("first error should come from the panicking driver, got %v", errs[0])
+	}
+
+	if errs[1].Driver != fallback {
+		t.Errorf("second error should come from the fallback, got %v", errs[1])
+	}
+}
+
+// asyncDriver принимает сообщения без ошибки, а о недоставке сообщает через ErrorReporter
+type asyncDriver struct {
+	memDriver
+	report ErrorHandler
+}
+
+func (d *asyncDriver) SetErrorHandler(h ErrorHandler) {
+	d.report = h
+}
+
+func (d *asyncDriver) Flush(ctx context.Context) error {
+	for _, msg := range d.messages() {
+		d.report(msg, errors.New("bulk rejected"))
+	}
+
+	return nil
+}
+
+func TestErrorReporterUsesFallback(t *testing.T) {
+	primary := &asyncDriver{}
+	fallback := &memDriver{}
+	rec := &errorRecorder{}
+	l := newTestLogger(t, LoggerConfig{
+		Drivers:      []DriverConfig{{Driver: primary, Fallback: fallback}},
+		ErrorHandler: rec.handle,
+	})
+
+	if primary.report == nil {
+		t.Fatal("SetErrorHandler was not called")
+	}
+
+	l.NewLogEvent().Log(context.Background(), "msg")
+	shutdown(t, l)
+
+	if got := messageData(fallback.messages()); !reflect.DeepEqual(got, []interface{}{"msg"}) {
+		t.Errorf("fallback got %v", got)
+	}
+
+	errs := rec.errors()
+	if len(errs) != 1 || errs[0] == nil || errs[0].Driver != primary {
+		t.Errorf("want one *DriverError from the async driver, got %v", errs)
+	}
+}
+
+func TestSlowDriverDoesNotBlockOthersByDefault(t *testing.T) {
+	slow := &memDriver{block: make(chan struct{})}
+	fast := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{slow, fast}})
+	defer close(slow.block)
+
+	logged := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			l.NewLogEvent().Log(context.Background(), i)
+		}
+		close(logged)
+	}()
+
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("Log hung on a blocked driver with Buffer 0")
+	}
+
+	waitMessages(t, fast, 5)
+}
+
+func TestFullDriverQueueDoesNotBlockFanOut(t *testing.T) {
+	slow := &memDriver{block: make(chan struct{})}
+	fast := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{
+		OverflowTimeout: 5 * time.Millisecond,
+		Drivers:         []DriverConfig{{Driver: slow, Buffer: 1}, {Driver: fast}},
+	})
+
+	for i := 0; i < 20; i++ {
+		l.NewLogEvent().Log(context.Background(), i)
+	}
+
+	waitMessages(t, fast, 20)
+
+	close(slow.block)
+	shutdown(t, l)
+
+	// одно сообщение в PutMsg и одно в очереди, остальные отброшены по таймауту
+	dropped := l.Stats().DriverDropped["LOG"]
+	if n := uint64(len(slow.messages())); n+dropped != 20 || dropped == 0 {
+		t.Errorf("slow driver got %d, dropped %d, want 20 in total with drops", n, dropped)
+	}
+}
+
+func waitMessages(t *testing.T, d *memDriver, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(d.messages()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("driver got %d of %d messages", len(d.messages()), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"os"
+	"runtime"
+	"time"
+)
+
+const defaultRecoverFlushTimeout = 5 * time.Second
+
+type RecoverOptions struct {
+	// Logger событие, от которого пишется ALERT, по умолчанию FromContext(ctx)
+	Logger *LogEvent
+	// RePanic повторить панику после доставки сообщения
+	RePanic bool
+	// Exit завершить процесс с кодом ExitCode после доставки сообщения
+	Exit bool
+	// ExitCode код выхода для Exit, по умолчанию 1
+	ExitCode int
+	// FlushTimeout сколько ждать доставки сообщения перед RePanic или Exit, по умолчанию 5s
+	FlushTimeout time.Duration
+}
+
+// Recover перехватывает панику и логирует ее как ALERT со стеком паникующей горутины.
+// Вызывается только через defer:
+//
+//	defer logger.Recover(ctx, logger.RecoverOptions{Logger: l.NewLogEvent()})
+func Recover(ctx context.Context, opts RecoverOptions) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+
+	opts.report(ctx, rec, panicPCs())
+}
+
+// ReportPanic логирует уже перехваченную панику rec как ALERT со стеком паникующей горутины, см. Recover.
+// Для middleware, которым после recover нужно еще ответить клиенту; вызывается из той же deferred функции:
+//
+//	defer func() {
+//		if rec := recover(); rec != nil {
+//			logger.ReportPanic(ctx, rec, logger.RecoverOptions{Logger: ev})
+//			...
+//		}
+//	}()
+func ReportPanic(ctx context.Context, rec interface{}, opts RecoverOptions) {
+	opts.report(ctx, rec, panicPCs())
+}
+
+// Go запускает fn в горутине, паника в которой логируется как ALERT, см. Recover
+func Go(ctx context.Context, fn func(ctx context.Context), opts ...RecoverOptions) {
+	var o RecoverOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	go func() {
+		defer Recover(ctx, o)
+		fn(ctx)
+	}()
+}
+
+func (opts RecoverOptions) report(ctx context.Context, rec interface{}, pcs []uintptr) {
+	err := &PanicError{Value: rec}
+
+	if opts.Logger == nil {
+		opts.Logger = eventFromContext(ctx)
+	}
+
+	if opts.Logger == nil || opts.Logger.l == nil {
+		log.Printf("%s\n%s", err.Error(), formatTrace(pcs))
+	} else {
+		opts.Logger.logPCs(ctx, ALERT, err, pcs)
+	}
+
+	if !opts.RePanic && !opts.Exit {
+		return
+	}
+
+	if opts.Logger != nil && opts.Logger.l != nil {
+		timeout := opts.FlushTimeout
+		if timeout <= 0 {
+			timeout = defaultRecoverFlushTimeout
+		}
+
+		flushCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		_ = opts.Logger.l.Flush(flushCtx)
+		cancel()
+	}
+
+	if opts.Exit {
+		code := opts.ExitCode
+		if code == 0 {
+			code = 1
+		}
+		os.Exit(code)
+	}
+
+	panic(rec)
+}
+
+// panicPCs стек паникующей горутины: из deferred функции отрезаются фреймы до runtime.gopanic включительно
+func panicPCs() []uintptr {
+	pcs := callers(1)
+	for i, pc := range pcs {
+		fn := runtime.FuncForPC(pc - 1)
+		if fn != nil && fn.Name() == "runtime.gopanic" {
+			return pcs[i+1:]
+		}
+	}
+
+	return pcs
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+//go:noinline
+func panicky(v interface{}) {
+	panic(v)
+}
+
+// frameFuncs имена функций стека сообщения, начиная с места вызова
+func frameFuncs(m Message) []string {
+	var funcs []string
+	frames := runtime.CallersFrames(m.PCs)
+	for {
+		f, more := frames.Next()
+		funcs = append(funcs, f.Function)
+		if !more {
+			return funcs
+		}
+	}
+}
+
+func checkPanicStack(t *testing.T, m Message) {
+	t.Helper()
+
+	funcs := frameFuncs(m)
+	if len(funcs) == 0 || !strings.HasSuffix(funcs[0], ".panicky") {
+		t.Fatalf("stack starts at %v, want panicky", funcs)
+	}
+
+	for _, fn := range funcs {
+		if strings.HasPrefix(fn, "runtime.gopanic") || strings.HasSuffix(fn, ".Recover") ||
+			strings.HasSuffix(fn, ".ReportPanic") || strings.HasSuffix(fn, ".panicPCs") {
+			t.Errorf("stack contains the recovering frame %s: %v", fn, funcs)
+		}
+	}
+}
+
+func TestRecoverLogsPanickingStack(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+
+	func() {
+		defer Recover(context.Background(), RecoverOptions{Logger: l.NewLogEvent()})
+		panicky("boom")
+	}()
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+
+	if msgs[0].MessageType != "ALERT" {
+		t.Errorf("got %s, want ALERT", msgs[0].MessageType)
+	}
+
+	if want := (&PanicError{Value: "boom"}).Error(); msgs[0].Data != want {
+		t.Errorf("got %v, want %q", msgs[0].Data, want)
+	}
+
+	checkPanicStack(t, msgs[0])
+}
+
+func TestReportPanic(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+	ctx := NewContext(context.Background(), l.NewLogEvent())
+	cause := errors.New("broken")
+
+	func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				ReportPanic(ctx, rec, RecoverOptions{})
+			}
+		}()
+		panicky(cause)
+	}()
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+
+	if want := (&PanicError{Value: cause}).Error(); msgs[0].Data != want {
+		t.Errorf("got %v, want %q", msgs[0].Data, want)
+	}
+
+	checkPanicStack(t, msgs[0])
+}
+
+func TestRecoverRePanicAfterFlush(t *testing.T) {
+	d := &memDriver{delay: 20 * time.Millisecond}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+	defer shutdown(t, l)
+
+	var delivered int
+	rec := func() (rec interface{}) {
+		defer func() {
+			rec = recover()
+			delivered = len(d.messages())
+		}()
+		defer Recover(context.Background(), RecoverOptions{Logger: l.NewLogEvent(), RePanic: true})
+		panicky("boom")
+		return nil
+	}()
+
+	if rec != "boom" {
+		t.Errorf("re-panicked with %v, want boom", rec)
+	}
+
+	if delivered != 1 {
+		t.Errorf("%d messages delivered before the re-panic, want 1", delivered)
+	}
+}
+
+func TestGoRecovers(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+	defer shutdown(t, l)
+
+	Go(context.Background(), func(ctx context.Context) {
+		panicky("boom")
+	}, RecoverOptions{Logger: l.NewLogEvent()})
+
+	waitMessages(t, d, 1)
+	m := d.messages()[0]
+	if m.MessageType != "ALERT" || m.Data != (&PanicError{Value: "boom"}).Error() {
+		t.Errorf("unexpected message %+v", m)
+	}
+
+	checkPanicStack(t, m)
+}
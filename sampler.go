@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	samplerBuckets         = 4096
+	defaultSamplerInterval = time.Second
+)
+
+// Sampling настройки сэмплирования уровня: за каждый Interval по каждому ключу сообщения
+// пропускаются первые First сообщений, дальше только каждое Thereafter-е.
+// Ключ сообщения это место вызова и текст, если данные сообщения строка
+type Sampling struct {
+	// Interval по умолчанию 1s
+	Interval time.Duration
+	First    uint64
+	// Thereafter 0 значит после First сообщений до конца интервала ничего не пропускается
+	Thereafter uint64
+}
+
+type samplerCounter struct {
+	resetAt int64
+	n       uint64
+}
+
+func (c *samplerCounter) inc(now int64, interval int64) uint64 {
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if resetAt > now {
+		return atomic.AddUint64(&c.n, 1)
+	}
+
+	atomic.StoreUint64(&c.n, 1)
+	atomic.StoreInt64(&c.resetAt, now+interval)
+
+	return 1
+}
+
+type levelSampler struct {
+	interval   int64
+	first      uint64
+	thereafter uint64
+	counters   [samplerBuckets]samplerCounter
+}
+
+type sampler struct {
+	levels  map[int]*levelSampler
+	sampled *levelCounter
+}
+
+func newSampler(config map[int]Sampling) *sampler {
+	if len(config) == 0 {
+		return nil
+	}
+
+	s := &sampler{
+		levels:  make(map[int]*levelSampler, len(config)),
+		sampled: &levelCounter{},
+	}
+	for level, cfg := range config {
+		interval := cfg.Interval
+		if interval <= 0 {
+			interval = defaultSamplerInterval
+		}
+
+		s.levels[level] = &levelSampler{
+			interval:   int64(interval),
+			first:      cfg.First,
+			thereafter: cfg.Thereafter,
+		}
+	}
+
+	return s
+}
+
+// enabled нужно ли сэмплировать уровень, чтобы не снимать место вызова зря
+func (s *sampler) enabled(level int) bool {
+	if s == nil {
+		return false
+	}
+
+	_, ok := s.levels[level]
+	return ok
+}
+
+// check решает, пропустить ли сообщение, отброшенные считаются в Stats.Sampled
+func (s *sampler) check(level int, pc uintptr, data interface{}) bool {
+	ls, ok := s.levels[level]
+	if !ok {
+		return true
+	}
+
+	c := &ls.counters[sampleKey(pc, data)%samplerBuckets]
+	n := c.inc(time.Now().UnixNano(), ls.interval)
+	if n <= ls.first || (ls.thereafter > 0 && (n-ls.first)%ls.thereafter == 0) {
+		return true
+	}
+
+	s.sampled.inc(level)
+
+	return false
+}
+
+// sampleKey fnv-1a по месту вызова и строковым данным сообщения
+func sampleKey(pc uintptr, data interface{}) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	h := uint64(offset)
+	for i := 0; i < 8; i++ {
+		h ^= uint64(pc >> (8 * i) & 0xff)
+		h *= prime
+	}
+
+	if s, ok := data.(string); ok {
+		for i := 0; i < len(s); i++ {
+			h ^= uint64(s[i])
+			h *= prime
+		}
+	}
+
+	return h
+}
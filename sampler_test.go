@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSamplerThresholds(t *testing.T) {
+	cases := []struct {
+		first, thereafter uint64
+		want              []int
+	}{
+		{3, 5, []int{1, 2, 3, 8, 13, 18}},
+		{2, 0, []int{1, 2}},
+		{0, 4, []int{4, 8, 12, 16, 20}},
+		{1, 1, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}},
+	}
+
+	for _, c := range cases {
+		s := newSampler(map[int]Sampling{LOG: {Interval: time.Hour, First: c.first, Thereafter: c.thereafter}})
+
+		var passed []int
+		for n := 1; n <= 20; n++ {
+			if s.check(LOG, 1, "msg") {
+				passed = append(passed, n)
+			}
+		}
+
+		if !reflect.DeepEqual(passed, c.want) {
+			t.Errorf("First %d, Thereafter %d: passed %v, want %v", c.first, c.thereafter, passed, c.want)
+		}
+
+		want := uint64(20 - len(c.want))
+		if got := s.sampled.snapshot()["LOG"]; got != want {
+			t.Errorf("First %d, Thereafter %d: sampled %d, want %d", c.first, c.thereafter, got, want)
+		}
+	}
+}
+
+func TestSamplerIntervalReset(t *testing.T) {
+	s := newSampler(map[int]Sampling{LOG: {Interval: 20 * time.Millisecond, First: 1}})
+
+	if !s.check(LOG, 1, "msg") || s.check(LOG, 1, "msg") {
+		t.Fatal("want only the first message in the interval to pass")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if !s.check(LOG, 1, "msg") {
+		t.Error("counter was not reset after the interval")
+	}
+}
+
+func TestSamplerKeysAndLevels(t *testing.T) {
+	s := newSampler(map[int]Sampling{LOG: {Interval: time.Hour, First: 1}})
+
+	if !s.check(LOG, 1, "a") || !s.check(LOG, 1, "b") || !s.check(LOG, 2, "a") {
+		t.Error("different call sites and texts must be sampled separately")
+	}
+
+	for i := 0; i < 5; i++ {
+		if !s.check(ERROR, 1, "a") {
+			t.Fatal("level without Sampling must not be sampled")
+		}
+	}
+
+	if s.enabled(ERROR) || !s.enabled(LOG) {
+		t.Error("enabled reports wrong levels")
+	}
+}
+
+func TestLoggerSampling(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{
+		Output:   []LogDriver{d},
+		Sampling: map[int]Sampling{DEBUG: {Interval: time.Hour, First: 2, Thereafter: 10}},
+	})
+
+	ev := l.NewLogEvent()
+	for i := 0; i < 25; i++ {
+		ev.Debug(context.Background(), "hot loop")
+	}
+	ev.Error(context.Background(), "hot loop")
+	shutdown(t, l)
+
+	// пропускаются 1, 2, 12 и 22 debug и error без сэмплирования
+	if n := len(d.messages()); n != 5 {
+		t.Errorf("got %d messages, want 5", n)
+	}
+
+	if got := l.Stats().Sampled; !reflect.DeepEqual(got, map[string]uint64{"DEBUG": 21}) {
+		t.Errorf("Stats().Sampled = %v, want DEBUG: 21", got)
+	}
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StackMode что сохраняется о стеке вызова для уровня
+type StackMode int
+
+const (
+	// StackFrames стек символизируется в фоновом воркере и попадает в Message.Stacktrace и Message.Trace
+	StackFrames StackMode = iota
+	// StackPCs сохраняются только адреса вызовов, драйверы получают фреймы через Message.GetStacktrace
+	StackPCs
+	// StackNone стек не сохраняется
+	StackNone
+)
+
+const maxStackDepth = 100
+
+func (l *Logger) stackMode(level int) StackMode {
+	mode, ok := l.Config.StackPolicy[level]
+	if !ok {
+		return StackFrames
+	}
+
+	return mode
+}
+
+// callers адреса вызовов, skip как у runtime.Callers без учета самой callers
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+
+	return pcs[:n]
+}
+
+// callSite адрес вызова метода логирования: первый из pcs, если стек уже снят, иначе снимается только он
+func callSite(pcs []uintptr) uintptr {
+	if len(pcs) > 0 {
+		return pcs[0]
+	}
+
+	// runtime.Callers, callSite, logPCs, log, Alert/Error/...
+	var pc [1]uintptr
+	runtime.Callers(5, pc[:])
+
+	return pc[0]
+}
+
+// lazyStacktrace символизирует стек один раз для всех драйверов, которым он нужен
+type lazyStacktrace struct {
+	pcs  []uintptr
+	once sync.Once
+	st   *Stacktrace
+}
+
+func (s *lazyStacktrace) get() *Stacktrace {
+	s.once.Do(func() {
+		s.st = newStacktraceFromPCs(s.pcs)
+	})
+
+	return s.st
+}
+
+// GetStacktrace стек сообщения. Если уровень настроен на StackPCs, фреймы символизируются при первом вызове
+func (m Message) GetStacktrace() *Stacktrace {
+	if m.Stacktrace != nil || m.lazyStack == nil {
+		return m.Stacktrace
+	}
+
+	return m.lazyStack.get()
+}
+
+// formatTrace текстовый стек в формате, похожем на debug.Stack
+func formatTrace(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		b.WriteString(f.Function)
+		b.WriteString("(...)\n\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+		b.WriteByte('\n')
+
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+//go:noinline
+func logFromHere(ev *LogEvent) {
+	ev.Log(context.Background(), "msg")
+}
+
+// stackMessage одно сообщение LOG, залогированное из logFromHere с политикой policy
+func stackMessage(t *testing.T, policy map[int]StackMode) Message {
+	t.Helper()
+
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}, StackPolicy: policy})
+	logFromHere(l.NewLogEvent())
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+
+	return msgs[0]
+}
+
+func checkStartsAtCaller(t *testing.T, m Message) {
+	t.Helper()
+
+	if funcs := frameFuncs(m); len(funcs) == 0 || !strings.HasSuffix(funcs[0], ".logFromHere") {
+		t.Errorf("stack starts at %v, want logFromHere", funcs)
+	}
+}
+
+func TestStackFrames(t *testing.T) {
+	m := stackMessage(t, nil)
+
+	checkStartsAtCaller(t, m)
+
+	if !strings.Contains(strings.SplitN(m.Trace, "\n", 2)[0], ".logFromHere(") {
+		t.Errorf("trace starts with %q, want logFromHere", strings.SplitN(m.Trace, "\n", 2)[0])
+	}
+
+	if m.Stacktrace == nil || m.GetStacktrace() != m.Stacktrace {
+		t.Errorf("Stacktrace %v is not filled in the worker", m.Stacktrace)
+	}
+}
+
+func TestStackPCs(t *testing.T) {
+	m := stackMessage(t, map[int]StackMode{LOG: StackPCs})
+
+	checkStartsAtCaller(t, m)
+
+	if m.Stacktrace != nil || m.Trace != "" {
+		t.Errorf("frames symbolized in the worker: %v %q", m.Stacktrace, m.Trace)
+	}
+
+	st := m.GetStacktrace()
+	if st == nil {
+		t.Fatal("GetStacktrace returned nil")
+	}
+
+	if m.GetStacktrace() != st {
+		t.Error("GetStacktrace symbolized the stack twice")
+	}
+}
+
+func TestStackNone(t *testing.T) {
+	m := stackMessage(t, map[int]StackMode{LOG: StackNone})
+
+	if m.PCs != nil || m.Trace != "" || m.Stacktrace != nil || m.GetStacktrace() != nil {
+		t.Errorf("stack captured with StackNone: %v %q %v", m.PCs, m.Trace, m.Stacktrace)
+	}
+}
+
+func TestStackPolicyPerLevel(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}, StackPolicy: map[int]StackMode{LOG: StackNone}})
+	ev := l.NewLogEvent()
+	ev.Log(context.Background(), "log")
+	ev.Error(context.Background(), "error")
+	shutdown(t, l)
+
+	for _, m := range d.messages() {
+		if got := m.PCs != nil; got != (m.MessageType == "ERROR") {
+			t.Errorf("%s: stack captured %v", m.MessageType, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package logger
+
+import "sync/atomic"
+
+// Stats счетчики сообщений, которые не дошли до драйверов, с разбивкой по уровням
+type Stats struct {
+	// Dropped отброшены при переполнении общей очереди логгера
+	Dropped map[string]uint64
+	// DriverDropped отброшены при переполнении очередей отдельных драйверов
+	DriverDropped map[string]uint64
+	// Sampled отброшены сэмплированием LoggerConfig.Sampling
+	Sampled map[string]uint64
+	// Suppressed повторы, подавленные LoggerConfig.DedupWindow
+	Suppressed map[string]uint64
+	// Filtered отброшены LoggerConfig.Processors
+	Filtered map[string]uint64
+}
+
+// levelCounter счетчик по уровням, последняя ячейка для неизвестных уровней
+type levelCounter [TRACE + 2]uint64
+
+func (c *levelCounter) inc(level int) {
+	if level < ALERT || level > TRACE {
+		level = TRACE + 1
+	}
+
+	atomic.AddUint64(&c[level], 1)
+}
+
+func (c *levelCounter) snapshot() map[string]uint64 {
+	res := make(map[string]uint64)
+	for level := range c {
+		n := atomic.LoadUint64(&c[level])
+		if n == 0 {
+			continue
+		}
+
+		code, ok := levelSlug[level]
+		if !ok {
+			code = "UNKNOWN"
+		}
+		res[code] = n
+	}
+
+	return res
+}
+
+// Stats возвращает текущие значения счетчиков
+func (l *Logger) Stats() Stats {
+	res := Stats{
+		Dropped:       l.dropped.snapshot(),
+		DriverDropped: l.driverDropped.snapshot(),
+		Sampled:       map[string]uint64{},
+		Suppressed:    map[string]uint64{},
+		Filtered:      l.filtered.snapshot(),
+	}
+
+	if l.sampler != nil {
+		res.Sampled = l.sampler.sampled.snapshot()
+	}
+
+	if l.dedup != nil {
+		res.Suppressed = l.dedup.suppressed.snapshot()
+	}
+
+	return res
+}
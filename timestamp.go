@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"strconv"
+	"time"
+)
+
+// Форматы LoggerConfig.TimeFormat, кроме них можно указать любой layout для time.Format
+const (
+	// TimeFormatDefault формат по умолчанию, без долей секунды
+	TimeFormatDefault      = "2006-01-02 15:04:05"
+	TimeFormatRFC3339      = time.RFC3339
+	TimeFormatRFC3339Milli = "2006-01-02T15:04:05.000Z07:00"
+	TimeFormatRFC3339Nano  = time.RFC3339Nano
+	// TimeFormatUnixMilli миллисекунды с начала эпохи
+	TimeFormatUnixMilli = "unixmilli"
+	// TimeFormatUnixMicro микросекунды с начала эпохи
+	TimeFormatUnixMicro = "unixmicro"
+	// TimeFormatUnixNano наносекунды с начала эпохи
+	TimeFormatUnixNano = "unixnano"
+)
+
+func (l *Logger) formatTime(t time.Time) string {
+	switch l.Config.TimeFormat {
+	case TimeFormatUnixMilli:
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case TimeFormatUnixMicro:
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case TimeFormatUnixNano:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	case "":
+		return t.In(l.timeLocation()).Format(TimeFormatDefault)
+	default:
+		return t.In(l.timeLocation()).Format(l.Config.TimeFormat)
+	}
+}
+
+func (l *Logger) timeLocation() *time.Location {
+	if l.Config.TimeLocation == nil {
+		return time.UTC
+	}
+
+	return l.Config.TimeLocation
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC)
+	zone := time.FixedZone("UTC+3", 3*60*60)
+
+	tests := []struct {
+		format   string
+		location *time.Location
+		want     string
+	}{
+		{"", nil, "2024-03-01 12:30:45"},
+		{"", zone, "2024-03-01 15:30:45"},
+		{TimeFormatRFC3339, nil, "2024-03-01T12:30:45Z"},
+		{TimeFormatRFC3339Milli, zone, "2024-03-01T15:30:45.123+03:00"},
+		{TimeFormatRFC3339Nano, nil, "2024-03-01T12:30:45.123456789Z"},
+		{TimeFormatUnixMilli, zone, "1709296245123"},
+		{TimeFormatUnixMicro, nil, "1709296245123456"},
+		{TimeFormatUnixNano, nil, "1709296245123456789"},
+		{"02.01.2006 15:04", zone, "01.03.2024 15:30"},
+	}
+
+	for _, tt := range tests {
+		l := &Logger{Config: LoggerConfig{TimeFormat: tt.format, TimeLocation: tt.location}}
+		if got := l.formatTime(ts); got != tt.want {
+			t.Errorf("format %q, location %v: got %s, want %s", tt.format, tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestTimestampAndSeqTakenAtCallSite(t *testing.T) {
+	l, d := blockedLogger(t, LoggerConfig{TimeFormat: TimeFormatUnixNano})
+	ev := l.NewLogEvent()
+
+	type call struct{ before, after time.Time }
+	calls := make([]call, 5)
+	for i := range calls {
+		calls[i].before = time.Now()
+		ev.Log(context.Background(), i)
+		calls[i].after = time.Now()
+		time.Sleep(time.Millisecond)
+	}
+
+	// драйвер получает сообщения заметно позже вызова
+	time.Sleep(20 * time.Millisecond)
+	delivered := time.Now()
+	close(d.block)
+	shutdown(t, l)
+
+	msgs := d.messages()[1:]
+	if len(msgs) != len(calls) {
+		t.Fatalf("got %d messages, want %d", len(msgs), len(calls))
+	}
+
+	var prevSeq uint64
+	for _, m := range msgs {
+		i := m.Data.(int)
+		if m.Timestamp.Before(calls[i].before) || m.Timestamp.After(calls[i].after) || !m.Timestamp.Before(delivered) {
+			t.Errorf("message %d: timestamp %s is not within its call [%s, %s]", i, m.Timestamp, calls[i].before, calls[i].after)
+		}
+
+		if want := l.formatTime(m.Timestamp); m.Time != want {
+			t.Errorf("message %d: date %s, want %s", i, m.Time, want)
+		}
+
+		if m.Seq <= prevSeq {
+			t.Errorf("message %d: seq %d after %d", i, m.Seq, prevSeq)
+		}
+		prevSeq = m.Seq
+	}
+}
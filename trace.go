@@ -30,11 +30,16 @@ func NewStacktrace() *Stacktrace {
 	pcs := make([]uintptr, 100)
 	n := runtime.Callers(1, pcs)
 
-	if n == 0 {
+	return newStacktraceFromPCs(pcs[:n])
+}
+
+// newStacktraceFromPCs creates a stacktrace from program counters captured earlier.
+func newStacktraceFromPCs(pcs []uintptr) *Stacktrace {
+	if len(pcs) == 0 {
 		return nil
 	}
 
-	frames := extractFrames(pcs[:n])
+	frames := extractFrames(pcs)
 	frames = filterFrames(frames)
 
 	stacktrace := Stacktrace{
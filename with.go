@@ -0,0 +1,99 @@
+package logger
+
+import "net/http"
+
+const (
+	// TagType поле становится тегом сообщения, см. Tag
+	TagType FieldType = iota + AnyType + 1
+	// ExtraType поле попадает в экстру сообщения, см. Extra
+	ExtraType
+	// UserType поле задает пользователя сообщения, см. User
+	UserType
+	// RequestType поле задает запрос сообщения, см. Request
+	RequestType
+)
+
+// Tag поле для With, которое добавляет тег
+func Tag(key, val string) Field {
+	return Field{Key: key, Type: TagType, str: val}
+}
+
+// Extra поле для With, которое добавляет значение в экстру
+func Extra(key string, val interface{}) Field {
+	return Field{Key: key, Type: ExtraType, iface: val}
+}
+
+// User поле для With, которое задает пользователя. Непустые поля дополняют уже заданного пользователя
+func User(user *UserForLog) Field {
+	return Field{Type: UserType, iface: user}
+}
+
+// Request поле для With, которое задает запрос
+func Request(r *http.Request) Field {
+	return Field{Type: RequestType, iface: r}
+}
+
+// With возвращает новое событие с полями, теги, экстра, пользователь и запрос задаются через Tag, Extra, User и Request
+func (l *Logger) With(fields ...Field) *LogEvent {
+	return l.NewLogEvent().With(fields...)
+}
+
+// With возвращает новое событие, которое наследует теги, экстру, поля, пользователя и запрос и дополняет их fields.
+// Исходное событие не меняется, поэтому событие, полученное через With, можно использовать из разных горутин,
+// пока у него не вызываются изменяющие методы WithTag, WithExtra и т.д.
+func (e *LogEvent) With(fields ...Field) *LogEvent {
+	c := e.clone()
+	c.apply(fields)
+
+	return c
+}
+
+func (e *LogEvent) apply(fields []Field) {
+	for _, f := range fields {
+		switch f.Type {
+		case TagType:
+			if e.Tags == nil {
+				e.Tags = make(map[string]string)
+			}
+			e.Tags[f.Key] = f.str
+		case ExtraType:
+			if e.Extra == nil {
+				e.Extra = make(map[string]interface{})
+			}
+			e.Extra[f.Key] = f.iface
+		case UserType:
+			if user, ok := f.iface.(*UserForLog); ok && user != nil {
+				e.User = mergeUser(e.User, user)
+			}
+		case RequestType:
+			if r, ok := f.iface.(*http.Request); ok && r != nil {
+				e.Request = r
+			}
+		default:
+			e.Fields = append(e.Fields, f)
+		}
+	}
+}
+
+// mergeUser новый пользователь, в котором непустые поля next дополняют base
+func mergeUser(base, next *UserForLog) *UserForLog {
+	if base == nil {
+		return next
+	}
+
+	res := *base
+	if next.Email != "" {
+		res.Email = next.Email
+	}
+	if next.ID != "" {
+		res.ID = next.ID
+	}
+	if next.IPAddress != "" {
+		res.IPAddress = next.IPAddress
+	}
+	if next.Username != "" {
+		res.Username = next.Username
+	}
+
+	return &res
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWithConcurrentChildrenIsolated(t *testing.T) {
+	d := &memDriver{}
+	l := newTestLogger(t, LoggerConfig{Output: []LogDriver{d}})
+
+	base := l.With(
+		Tag("service", "api"),
+		Extra("base", true),
+		User(&UserForLog{Email: "base@example.com"}),
+		String("base", "v"),
+	)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			id := strconv.Itoa(i)
+			child := base.With(Tag("child", id), Extra("child", i), User(&UserForLog{ID: id}), String("child", id))
+			// изменяющие методы дочернего события не трогают base и соседей
+			child.WithTag("own", id).WithExtra("own", i)
+			child.Log(context.Background(), id)
+		}(i)
+	}
+	wg.Wait()
+	shutdown(t, l)
+
+	msgs := d.messages()
+	if len(msgs) != n {
+		t.Fatalf("got %d messages, want %d", len(msgs), n)
+	}
+
+	for _, m := range msgs {
+		id := m.Data.(string)
+		i, _ := strconv.Atoi(id)
+
+		wantTags := map[string]string{"service": "api", "child": id, "own": id}
+		if !reflect.DeepEqual(m.Tags, wantTags) {
+			t.Errorf("message %s: tags %v, want %v", id, m.Tags, wantTags)
+		}
+
+		wantExtra := map[string]interface{}{"base": true, "child": i, "own": i}
+		if !reflect.DeepEqual(m.Extra, wantExtra) {
+			t.Errorf("message %s: extra %v, want %v", id, m.Extra, wantExtra)
+		}
+
+		if m.User == nil || m.User.ID != id || m.User.Email != "base@example.com" {
+			t.Errorf("message %s: user %+v, want merged with base", id, m.User)
+		}
+
+		wantFields := map[string]interface{}{"base": "v", "child": id}
+		if !reflect.DeepEqual(m.Fields.Map(), wantFields) {
+			t.Errorf("message %s: fields %v, want %v", id, m.Fields.Map(), wantFields)
+		}
+	}
+
+	if !reflect.DeepEqual(base.Tags, map[string]string{"service": "api"}) ||
+		!reflect.DeepEqual(base.Extra, map[string]interface{}{"base": true}) ||
+		!reflect.DeepEqual(base.User, &UserForLog{Email: "base@example.com"}) || len(base.Fields) != 1 {
+		t.Errorf("base event changed: %+v", base)
+	}
+}